@@ -0,0 +1,651 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/millenc/golatch"
+)
+
+var commands = []command{
+	{"pair", "<token>", "pairs an account with a pairing token (--id pairs an account ID instead)", runPair},
+	{"unpair", "<account ID>", "unpairs an account", runUnpair},
+	{"lock", "<account ID>", "locks an account (or one of its operations or instances with --operation and --instance)", runLock},
+	{"unlock", "<account ID>", "unlocks an account (or one of its operations or instances with --operation and --instance)", runUnlock},
+	{"status", "<account ID>", "shows the status of an account (exits with 3 if the latch is off)", runStatus},
+	{"history", "<account ID>", "shows the history of an account", runHistory},
+	{"operation", "add|update|delete|show", "manages the operations of the application", runOperation},
+	{"instance", "add|update|delete|list", "manages the instances of an account", runInstance},
+	{"totp", "create|show|validate|delete", "manages the TOTPs of the users of the application", runTotp},
+	{"application", "add|update|delete|list", "manages the applications of the user", runApplication},
+	{"subscription", "", "shows the subscription of the user", runSubscription},
+}
+
+var operationCommands = []command{
+	{"add", "", "adds an operation", runOperationAdd},
+	{"update", "<operation ID>", "updates an operation", runOperationUpdate},
+	{"delete", "<operation ID>", "deletes an operation", runOperationDelete},
+	{"show", "[operation ID]", "shows an operation (or all of them)", runOperationShow},
+}
+
+var instanceCommands = []command{
+	{"add", "<account ID> <name>...", "adds instances to an account (or to one of its operations with --operation)", runInstanceAdd},
+	{"update", "<account ID> <instance ID>", "renames an instance", runInstanceUpdate},
+	{"delete", "<account ID> <instance ID>", "deletes an instance", runInstanceDelete},
+	{"list", "<account ID>", "lists the instances of an account (or of one of its operations with --operation)", runInstanceList},
+}
+
+var totpCommands = []command{
+	{"create", "<user ID> <common name>", "creates a TOTP for a user", runTotpCreate},
+	{"show", "<TOTP ID>", "shows the provisioning data of a TOTP", runTotpShow},
+	{"validate", "<TOTP ID> <code>", "validates a code of a TOTP", runTotpValidate},
+	{"delete", "<TOTP ID>", "deletes a TOTP", runTotpDelete},
+}
+
+var applicationCommands = []command{
+	{"add", "", "adds an application", runApplicationAdd},
+	{"update", "<application ID>", "updates an application", runApplicationUpdate},
+	{"delete", "<application ID>", "deletes an application", runApplicationDelete},
+	{"list", "", "lists the applications", runApplicationList},
+}
+
+func runPair(c *cli, args []string) error {
+	flags := c.newFlagSet("golatch pair", "<token>")
+	byId := flags.Bool("id", false, "pair using an account ID instead of a token (only in test and development environments)")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	var response *golatch.LatchPairResponse
+	if *byId {
+		response, err = latch.PairWithIdWithContext(ctx, args[0])
+	} else {
+		response, err = latch.PairWithContext(ctx, args[0])
+	}
+	if err != nil {
+		return err
+	}
+	return c.print(response, func(w io.Writer) {
+		row(w, "ACCOUNT ID")
+		row(w, response.AccountId())
+	})
+}
+
+func runUnpair(c *cli, args []string) error {
+	args, err := c.parse(c.newFlagSet("golatch unpair", "<account ID>"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return latch.UnpairWithContext(ctx, args[0])
+}
+
+func runLock(c *cli, args []string) error {
+	return runLockOrUnlock(c, "lock", args)
+}
+
+func runUnlock(c *cli, args []string) error {
+	return runLockOrUnlock(c, "unlock", args)
+}
+
+func runLockOrUnlock(c *cli, name string, args []string) error {
+	flags := c.newFlagSet("golatch "+name, "<account ID>")
+	operationId := flags.String("operation", "", "ID of the operation")
+	instanceId := flags.String("instance", "", "ID of the instance")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	switch {
+	case name == "lock" && *instanceId != "":
+		return latch.LockInstanceWithContext(ctx, args[0], *operationId, *instanceId)
+	case *instanceId != "":
+		return latch.UnlockInstanceWithContext(ctx, args[0], *operationId, *instanceId)
+	case name == "lock" && *operationId != "":
+		return latch.LockOperationWithContext(ctx, args[0], *operationId)
+	case name == "lock":
+		return latch.LockWithContext(ctx, args[0])
+	case *operationId != "":
+		return latch.UnlockOperationWithContext(ctx, args[0], *operationId)
+	}
+	return latch.UnlockWithContext(ctx, args[0])
+}
+
+func runStatus(c *cli, args []string) error {
+	flags := c.newFlagSet("golatch status", "<account ID>")
+	operationId := flags.String("operation", "", "ID of the operation")
+	instanceId := flags.String("instance", "", "ID of the instance")
+	nootp := flags.Bool("nootp", false, "don't generate a one time password")
+	silent := flags.Bool("silent", false, "don't send push notifications")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	response, err := latch.StatusWithOptions(ctx, args[0], golatch.WithOperation(*operationId), golatch.WithInstance(*instanceId), golatch.WithNoOtp(*nootp), golatch.WithSilent(*silent))
+	if err != nil {
+		return err
+	}
+
+	err = c.print(response, func(w io.Writer) {
+		row(w, "OPERATION", "STATUS", "TWO FACTOR TOKEN")
+		printStatus(w, response.Data.Operations, 0)
+	})
+	if err == nil && response.Status() == golatch.LATCH_STATUS_OFF {
+		return errLatchOff
+	}
+	return err
+}
+
+func printStatus(w io.Writer, operations map[string]golatch.LatchOperationStatus, depth int) {
+	for _, id := range sortedKeys(operations) {
+		operation := operations[id]
+		row(w, strings.Repeat("  ", depth)+id, operation.Status, valueOrDash(operation.TwoFactor.Token))
+		printStatus(w, operation.Operations, depth+1)
+	}
+}
+
+func runHistory(c *cli, args []string) error {
+	flags := c.newFlagSet("golatch history", "<account ID>")
+	from := flags.String("from", "", "start date (RFC 3339 or YYYY-MM-DD)")
+	to := flags.String("to", "", "end date (RFC 3339 or YYYY-MM-DD)")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	fromTime, err := parseTime(*from)
+	if err != nil {
+		return c.usageError("invalid --from date %q", *from)
+	}
+	toTime, err := parseTime(*to)
+	if err != nil {
+		return c.usageError("invalid --to date %q", *to)
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	response, err := latch.HistoryWithContext(ctx, args[0], fromTime, toTime)
+	if err != nil {
+		return err
+	}
+	return c.print(response, func(w io.Writer) {
+		application := response.Application()
+		fmt.Fprintf(w, "Application:\t%s (%s)\n", application.Name, application.Status)
+		fmt.Fprintf(w, "Paired on:\t%s\n", formatMillis(application.PairedOn))
+		fmt.Fprintf(w, "Last seen:\t%s\n", formatMillis(response.LastSeen()))
+		fmt.Fprintf(w, "Entries:\t%d\n\n", response.HistoryCount())
+		row(w, "TIME", "ACTION", "WHAT", "VALUE", "WAS", "NAME", "IP")
+		for _, entry := range response.History() {
+			row(w, formatMillis(entry.Time), entry.Action, entry.What, valueOrDash(entry.Value), valueOrDash(entry.Was), valueOrDash(entry.Name), valueOrDash(entry.IP))
+		}
+	})
+}
+
+func runOperation(c *cli, args []string) error {
+	return c.dispatchSubcommand("operation", operationCommands, args)
+}
+
+//Registers the flags of the operation add/update commands
+func operationFlags(flags *flag.FlagSet, defaultValue string) (name *string, twoFactor *string, lockOnRequest *string) {
+	name = flags.String("name", "", "name of the operation")
+	twoFactor = flags.String("two-factor", defaultValue, "two factor option (MANDATORY, OPT_IN or DISABLED)")
+	lockOnRequest = flags.String("lock-on-request", defaultValue, "lock on request option (MANDATORY, OPT_IN or DISABLED)")
+	return name, twoFactor, lockOnRequest
+}
+
+func runOperationAdd(c *cli, args []string) error {
+	flags := c.newFlagSet("golatch operation add", "")
+	parentId := flags.String("parent", "", "ID of the parent operation (defaults to the application ID)")
+	name, twoFactor, lockOnRequest := operationFlags(flags, golatch.DISABLED)
+	if _, err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if *name == "" {
+		return c.usageError("missing --name")
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	if *parentId == "" {
+		*parentId = latch.AppID
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	response, err := latch.AddOperationWithContext(ctx, *parentId, *name, *twoFactor, *lockOnRequest)
+	if err != nil {
+		return err
+	}
+	return c.print(response, func(w io.Writer) {
+		row(w, "OPERATION ID")
+		row(w, response.OperationId())
+	})
+}
+
+func runOperationUpdate(c *cli, args []string) error {
+	flags := c.newFlagSet("golatch operation update", "<operation ID>")
+	name, twoFactor, lockOnRequest := operationFlags(flags, golatch.NOT_SET)
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return latch.UpdateOperationWithContext(ctx, args[0], *name, *twoFactor, *lockOnRequest)
+}
+
+func runOperationDelete(c *cli, args []string) error {
+	args, err := c.parse(c.newFlagSet("golatch operation delete", "<operation ID>"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return latch.DeleteOperationWithContext(ctx, args[0])
+}
+
+func runOperationShow(c *cli, args []string) error {
+	args, err := c.parse(c.newFlagSet("golatch operation show", "[operation ID]"), args, 0, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	operationId := ""
+	if len(args) > 0 {
+		operationId = args[0]
+	}
+	response, err := latch.ShowOperationWithContext(ctx, operationId)
+	if err != nil {
+		return err
+	}
+	return c.print(response, func(w io.Writer) {
+		row(w, "OPERATION ID", "NAME", "TWO FACTOR", "LOCK ON REQUEST")
+		printOperations(w, response.Operations(), 0)
+	})
+}
+
+func printOperations(w io.Writer, operations map[string]golatch.LatchOperation, depth int) {
+	for _, id := range sortedKeys(operations) {
+		operation := operations[id]
+		row(w, strings.Repeat("  ", depth)+id, operation.Name, valueOrDash(operation.TwoFactor), valueOrDash(operation.LockOnRequest))
+		printOperations(w, operation.Operations, depth+1)
+	}
+}
+
+func runInstance(c *cli, args []string) error {
+	return c.dispatchSubcommand("instance", instanceCommands, args)
+}
+
+func runInstanceAdd(c *cli, args []string) error {
+	flags := c.newFlagSet("golatch instance add", "<account ID> <name>...")
+	operationId := flags.String("operation", "", "ID of the operation")
+	args, err := c.parse(flags, args, 2, -1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	response, err := latch.AddInstanceWithContext(ctx, args[0], *operationId, args[1:]...)
+	if err != nil {
+		return err
+	}
+	return c.print(response, func(w io.Writer) {
+		row(w, "INSTANCE ID", "NAME")
+		for _, id := range sortedKeys(response.Instances()) {
+			row(w, id, response.Instances()[id])
+		}
+	})
+}
+
+func runInstanceUpdate(c *cli, args []string) error {
+	flags := c.newFlagSet("golatch instance update", "<account ID> <instance ID>")
+	operationId := flags.String("operation", "", "ID of the operation")
+	name := flags.String("name", "", "name of the instance")
+	args, err := c.parse(flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	if *name == "" {
+		return c.usageError("missing --name")
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return latch.UpdateInstanceWithContext(ctx, args[0], *operationId, args[1], *name)
+}
+
+func runInstanceDelete(c *cli, args []string) error {
+	flags := c.newFlagSet("golatch instance delete", "<account ID> <instance ID>")
+	operationId := flags.String("operation", "", "ID of the operation")
+	args, err := c.parse(flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return latch.DeleteInstanceWithContext(ctx, args[0], *operationId, args[1])
+}
+
+func runInstanceList(c *cli, args []string) error {
+	flags := c.newFlagSet("golatch instance list", "<account ID>")
+	operationId := flags.String("operation", "", "ID of the operation")
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	response, err := latch.ShowInstancesWithContext(ctx, args[0], *operationId)
+	if err != nil {
+		return err
+	}
+	return c.print(response, func(w io.Writer) {
+		row(w, "INSTANCE ID", "NAME")
+		for _, id := range sortedKeys(response.Instances()) {
+			row(w, id, response.Instances()[id].Name)
+		}
+	})
+}
+
+func runTotp(c *cli, args []string) error {
+	return c.dispatchSubcommand("totp", totpCommands, args)
+}
+
+//Returns a client for the TOTP endpoints, which use version 3.0 of the API unless another one has been configured
+func (c *cli) totpLatch() (*golatch.Latch, error) {
+	latch, err := c.latch()
+	if err == nil && latch.APIVersion == "" {
+		latch.SetAPIVersion(golatch.API_VERSION_3_0)
+	}
+	return latch, err
+}
+
+func printTotp(c *cli, response *golatch.LatchTotpResponse) error {
+	return c.print(response, func(w io.Writer) {
+		totp := response.Totp()
+		fmt.Fprintf(w, "TOTP ID:\t%s\n", totp.TotpId)
+		fmt.Fprintf(w, "Identity:\t%s\n", totp.Identity)
+		fmt.Fprintf(w, "Issuer:\t%s\n", valueOrDash(totp.Issuer))
+		fmt.Fprintf(w, "Secret:\t%s\n", totp.Secret)
+		fmt.Fprintf(w, "Algorithm:\t%s (%d digits, %ds period)\n", valueOrDash(totp.Algorithm), totp.Digits, totp.Period)
+		fmt.Fprintf(w, "Created at:\t%s\n", formatMillis(totp.CreatedAt))
+		fmt.Fprintf(w, "URI:\t%s\n", totp.ProvisioningURI())
+	})
+}
+
+func runTotpCreate(c *cli, args []string) error {
+	args, err := c.parse(c.newFlagSet("golatch totp create", "<user ID> <common name>"), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	latch, err := c.totpLatch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	response, err := latch.CreateTotpWithContext(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return printTotp(c, response)
+}
+
+func runTotpShow(c *cli, args []string) error {
+	args, err := c.parse(c.newFlagSet("golatch totp show", "<TOTP ID>"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.totpLatch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	response, err := latch.GetTotpWithContext(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return printTotp(c, response)
+}
+
+func runTotpValidate(c *cli, args []string) error {
+	args, err := c.parse(c.newFlagSet("golatch totp validate", "<TOTP ID> <code>"), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	latch, err := c.totpLatch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return latch.ValidateTotpWithContext(ctx, args[0], args[1])
+}
+
+func runTotpDelete(c *cli, args []string) error {
+	args, err := c.parse(c.newFlagSet("golatch totp delete", "<TOTP ID>"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.totpLatch()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return latch.DeleteTotpWithContext(ctx, args[0])
+}
+
+func runApplication(c *cli, args []string) error {
+	return c.dispatchSubcommand("application", applicationCommands, args)
+}
+
+//Registers the flags of the application add/update commands
+//Updates only send the values of the flags provided (the rest default to NOT_SET)
+func applicationFlags(flags *flag.FlagSet, defaultValue string) *golatch.LatchApplicationInfo {
+	info := &golatch.LatchApplicationInfo{}
+	flags.StringVar(&info.Name, "name", "", "name of the application")
+	flags.StringVar(&info.ContactEmail, "contact-email", "", "contact email")
+	flags.StringVar(&info.ContactPhone, "contact-phone", "", "contact phone")
+	flags.StringVar(&info.TwoFactor, "two-factor", defaultValue, "two factor option (MANDATORY, OPT_IN or DISABLED)")
+	flags.StringVar(&info.LockOnRequest, "lock-on-request", defaultValue, "lock on request option (MANDATORY, OPT_IN or DISABLED)")
+	return info
+}
+
+func runApplicationAdd(c *cli, args []string) error {
+	flags := c.newFlagSet("golatch application add", "")
+	info := applicationFlags(flags, golatch.DISABLED)
+	if _, err := c.parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if info.Name == "" {
+		return c.usageError("missing --name")
+	}
+	latch, err := c.latchUser()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	response, err := latch.AddApplicationWithContext(ctx, info)
+	if err != nil {
+		return err
+	}
+	return c.print(response, func(w io.Writer) {
+		row(w, "APPLICATION ID", "SECRET")
+		row(w, response.AppID(), response.Secret())
+	})
+}
+
+func runApplicationUpdate(c *cli, args []string) error {
+	flags := c.newFlagSet("golatch application update", "<application ID>")
+	info := applicationFlags(flags, golatch.NOT_SET)
+	args, err := c.parse(flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latchUser()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return latch.UpdateApplicationWithContext(ctx, args[0], info)
+}
+
+func runApplicationDelete(c *cli, args []string) error {
+	args, err := c.parse(c.newFlagSet("golatch application delete", "<application ID>"), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	latch, err := c.latchUser()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return latch.DeleteApplicationWithContext(ctx, args[0])
+}
+
+func runApplicationList(c *cli, args []string) error {
+	if _, err := c.parse(c.newFlagSet("golatch application list", ""), args, 0, 0); err != nil {
+		return err
+	}
+	latch, err := c.latchUser()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	response, err := latch.ShowApplicationsWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	return c.print(response, func(w io.Writer) {
+		row(w, "APPLICATION ID", "NAME", "TWO FACTOR", "LOCK ON REQUEST", "CONTACT EMAIL", "OPERATIONS")
+		applications := response.Applications()
+		for _, id := range sortedKeys(applications) {
+			application := applications[id]
+			row(w, id, application.Name, valueOrDash(application.TwoFactor), valueOrDash(application.LockOnRequest), valueOrDash(application.ContactEmail), len(application.Operations))
+		}
+	})
+}
+
+func runSubscription(c *cli, args []string) error {
+	if _, err := c.parse(c.newFlagSet("golatch subscription", ""), args, 0, 0); err != nil {
+		return err
+	}
+	latch, err := c.latchUser()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+
+	response, err := latch.SubscriptionWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	return c.print(response, func(w io.Writer) {
+		fmt.Fprintf(w, "Subscription:\t%s\n\n", response.ID())
+		row(w, "RESOURCE", "IN USE", "LIMIT")
+		row(w, "applications", response.Applications().InUse, formatLimit(response.Applications().Limit))
+		row(w, "users", response.Users().InUse, formatLimit(response.Users().Limit))
+		operations := response.Operations()
+		for _, id := range sortedKeys(operations) {
+			row(w, "operations ("+id+")", operations[id].InUse, formatLimit(operations[id].Limit))
+		}
+	})
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+//Formats the limit of a resource (negative limits mean unlimited)
+func formatLimit(limit int) string {
+	if limit < 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(limit)
+}
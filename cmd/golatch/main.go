@@ -0,0 +1,337 @@
+//Command golatch is a command line client for the Latch API
+//
+//Usage:
+//
+//	golatch [flags] <command> [flags] [arguments]
+//
+//Run "golatch help" to get the list of commands.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/millenc/golatch"
+)
+
+//Exit codes
+const (
+	EXIT_OK        = 0
+	EXIT_ERROR     = 1 //the request failed
+	EXIT_USAGE     = 2 //wrong arguments or missing credentials
+	EXIT_LATCH_OFF = 3 //the latch is off (status command)
+)
+
+//Usage errors (exit with EXIT_USAGE)
+var errUsage = errors.New("usage error")
+
+//Latch is off (exit with EXIT_LATCH_OFF)
+var errLatchOff = errors.New("latch is off")
+
+//Options shared by all the commands. The client configuration set with flags overrides the one loaded with golatch.LoadConfig()
+type options struct {
+	golatch.Config
+	ConfigFile string
+	Profile    string
+	JSON       bool
+	Headers    headers
+}
+
+//Extra X-11Paths headers sent with the requests (--header can be repeated)
+type headers map[string]string
+
+//Implementation of the flag.Value interface
+func (h *headers) String() string {
+	if h == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*h))
+	for _, name := range sortedKeys(*h) {
+		pairs = append(pairs, name+"="+(*h)[name])
+	}
+	return strings.Join(pairs, ",")
+}
+
+//Implementation of the flag.Value interface. Headers are set as name=value
+func (h *headers) Set(value string) error {
+	name, value, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected name=value, got %q", name)
+	}
+	if err := golatch.ValidateXHeaders(map[string]string{name: value}); err != nil {
+		return err
+	}
+	if *h == nil {
+		*h = make(headers)
+	}
+	(*h)[name] = value
+	return nil
+}
+
+type cli struct {
+	stdout  io.Writer
+	stderr  io.Writer
+	options options
+}
+
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(c *cli, args []string) error
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+//Runs the command line and returns the exit code
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	c := &cli{stdout: stdout, stderr: stderr}
+
+	flags := c.newFlagSet("golatch", "<command> [flags] [arguments]")
+	if err := parseFlags(flags, args); err != nil {
+		return c.exitCode(err)
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		c.printCommands()
+		return EXIT_USAGE
+	}
+
+	return c.exitCode(c.dispatch(commands, args))
+}
+
+//Runs the command named by the first argument
+func (c *cli) dispatch(commands []command, args []string) error {
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(c, args[1:])
+		}
+	}
+	if args[0] == "help" {
+		c.printCommands()
+		return nil
+	}
+	return c.usageError("unknown command %q (run \"golatch help\" to get the list of commands)", args[0])
+}
+
+//Runs a subcommand (like "operation add")
+func (c *cli) dispatchSubcommand(name string, subcommands []command, args []string) error {
+	if len(args) == 0 {
+		return c.usageError("missing %s command (one of %s)", name, commandNames(subcommands))
+	}
+	for _, cmd := range subcommands {
+		if cmd.name == args[0] {
+			return cmd.run(c, args[1:])
+		}
+	}
+	return c.usageError("unknown %s command %q (one of %s)", name, args[0], commandNames(subcommands))
+}
+
+func commandNames(commands []command) string {
+	names := make([]string, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.name
+	}
+	return strings.Join(names, ", ")
+}
+
+func (c *cli) printCommands() {
+	fmt.Fprintln(c.stderr, "Usage: golatch [flags] <command> [flags] [arguments]")
+	fmt.Fprintln(c.stderr, "\nCommands:")
+	w := tabwriter.NewWriter(c.stderr, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s %s\t%s\n", cmd.name, cmd.usage, cmd.description)
+	}
+	w.Flush()
+	fmt.Fprintln(c.stderr, "\nRun \"golatch <command> -h\" to get the flags of a command.")
+	fmt.Fprintf(c.stderr, "\nExit codes: %d ok, %d error, %d usage error, %d latch off.\n", EXIT_OK, EXIT_ERROR, EXIT_USAGE, EXIT_LATCH_OFF)
+}
+
+//Returns a flag set with the flags shared by all the commands
+func (c *cli) newFlagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: %s [flags] %s\n\nFlags:\n", name, usage)
+		flags.PrintDefaults()
+	}
+
+	//Defaults are the current values so the global flags can be set before or after the command
+	o := &c.options
+	flags.StringVar(&o.AppID, "app-id", o.AppID, "application ID (or "+golatch.ENV_APP_ID+")")
+	flags.StringVar(&o.SecretKey, "secret", o.SecretKey, "secret key of the application (or "+golatch.ENV_SECRET_KEY+")")
+	flags.StringVar(&o.UserID, "user-id", o.UserID, "user ID (or "+golatch.ENV_USER_ID+")")
+	flags.StringVar(&o.UserSecretKey, "user-secret", o.UserSecretKey, "secret key of the user (or "+golatch.ENV_USER_SECRET_KEY+")")
+	flags.StringVar(&o.APIURL, "api-url", o.APIURL, "URL of the API (or "+golatch.ENV_API_URL+")")
+	flags.StringVar(&o.APIVersion, "api-version", o.APIVersion, "version of the API, like "+golatch.API_VERSION_3_0+" (or "+golatch.ENV_API_VERSION+", defaults to "+golatch.API_VERSION+")")
+	flags.StringVar(&o.Proxy, "proxy", o.Proxy, "URL of the proxy (or "+golatch.ENV_PROXY+")")
+	flags.DurationVar(&o.Timeout, "timeout", o.Timeout, "timeout of the requests, like 10s (or "+golatch.ENV_TIMEOUT+")")
+	flags.StringVar(&o.ConfigFile, "config", o.ConfigFile, "config file (or "+golatch.ENV_CONFIG_FILE+", defaults to ~/"+golatch.DEFAULT_CONFIG_FILE+")")
+	flags.StringVar(&o.Profile, "profile", o.Profile, "profile of the config file (or "+golatch.ENV_PROFILE+", defaults to "+golatch.DEFAULT_PROFILE+")")
+	flags.BoolVar(&o.JSON, "json", o.JSON, "print the responses as JSON")
+	flags.Var(&o.Headers, "header", "extra "+golatch.API_X_11PATHS_HEADER_PREFIX+"* header sent and signed with the request, like "+golatch.API_X_11PATHS_HEADER_PREFIX+"Session=MySession (can be repeated)")
+	return flags
+}
+
+//Parses the arguments of a command (flags can be placed before or after the positional arguments) and checks the number of positional arguments
+func (c *cli) parse(flags *flag.FlagSet, args []string, min int, max int) ([]string, error) {
+	var positional []string
+	for {
+		if err := parseFlags(flags, args); err != nil {
+			return nil, err
+		}
+		if args = flags.Args(); len(args) == 0 {
+			break
+		}
+		positional, args = append(positional, args[0]), args[1:]
+	}
+
+	if len(positional) < min || (max >= 0 && len(positional) > max) {
+		flags.Usage()
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+//Parses the flags. The flag package already prints the errors, so they are translated to errUsage
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return errUsage
+	}
+	return err
+}
+
+//Loads the client configuration: flags override the environment variables and the profile of the config file
+func (c *cli) loadConfig() (*golatch.Config, error) {
+	config, err := golatch.LoadConfig(c.options.ConfigFile, c.options.Profile)
+	if err != nil {
+		return nil, err
+	}
+	config.Merge(&c.options.Config)
+	return config, nil
+}
+
+//Returns a client for the application API (that sends the extra headers set with --header)
+func (c *cli) latch() (*golatch.Latch, error) {
+	config, err := c.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	latch, err := golatch.NewLatchFromConfig(config)
+	if errors.Is(err, golatch.ErrMissingCredentials) {
+		return nil, c.usageError("missing application credentials (use --app-id and --secret, %s and %s or the config file)", golatch.ENV_APP_ID, golatch.ENV_SECRET_KEY)
+	}
+	if err == nil {
+		latch.SetXHeaders(c.options.Headers)
+	}
+	return latch, err
+}
+
+//Returns a client for the user API (that sends the extra headers set with --header)
+func (c *cli) latchUser() (*golatch.LatchUser, error) {
+	config, err := c.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	latch, err := golatch.NewLatchUserFromConfig(config)
+	if errors.Is(err, golatch.ErrMissingCredentials) {
+		return nil, c.usageError("missing user credentials (use --user-id and --user-secret, %s and %s or the config file)", golatch.ENV_USER_ID, golatch.ENV_USER_SECRET_KEY)
+	}
+	if err == nil {
+		latch.SetXHeaders(c.options.Headers)
+	}
+	return latch, err
+}
+
+//Returns the context of the requests (canceled on interrupt)
+func (c *cli) context() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+//Prints an usage error and returns errUsage
+func (c *cli) usageError(format string, a ...interface{}) error {
+	fmt.Fprintf(c.stderr, "golatch: "+format+"\n", a...)
+	return errUsage
+}
+
+//Gets the exit code for an error (printing it if needed)
+func (c *cli) exitCode(err error) int {
+	switch {
+	case err == nil:
+		return EXIT_OK
+	case errors.Is(err, flag.ErrHelp):
+		return EXIT_OK
+	case errors.Is(err, errUsage):
+		return EXIT_USAGE
+	case errors.Is(err, errLatchOff):
+		return EXIT_LATCH_OFF
+	}
+	fmt.Fprintf(c.stderr, "golatch: %v\n", err)
+	return EXIT_ERROR
+}
+
+//Prints a response: as JSON when --json is set or calling table otherwise
+func (c *cli) print(response interface{}, table func(w io.Writer)) error {
+	if c.options.JSON {
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(response)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	table(w)
+	return w.Flush()
+}
+
+//Prints a row of a table
+func row(w io.Writer, columns ...interface{}) {
+	for i, column := range columns {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, column)
+	}
+	fmt.Fprintln(w)
+}
+
+//Gets the keys of a map sorted
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//Formats a timestamp in milliseconds
+func formatMillis(millis int64) string {
+	if millis == 0 {
+		return "-"
+	}
+	return time.UnixMilli(millis).UTC().Format(time.RFC3339)
+}
+
+//Parses a date (RFC 3339 or YYYY-MM-DD)
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/millenc/golatch"
+	"github.com/millenc/golatch/golatchtest"
+)
+
+type testCLI struct {
+	server *golatchtest.Server
+}
+
+//Starts a fake server and sets the environment variables with the credentials of its application and user
+func newTestCLI(t *testing.T) *testCLI {
+	server := golatchtest.NewServer()
+	server.AddUser("MyUserID", "MyUserSecretKey")
+	server.AddApplication("MyUserID", "MyAppID", "MySecretKey", "My Application")
+
+	setTestEnv(t, map[string]string{
+		golatch.ENV_APP_ID:          "MyAppID",
+		golatch.ENV_SECRET_KEY:      "MySecretKey",
+		golatch.ENV_USER_ID:         "MyUserID",
+		golatch.ENV_USER_SECRET_KEY: "MyUserSecretKey",
+		golatch.ENV_API_URL:         server.URL,
+	})
+	return &testCLI{server: server}
+}
+
+//Sets the LATCH_* environment variables (clearing the ones not provided)
+func setTestEnv(t *testing.T, env map[string]string) {
+	for _, name := range []string{golatch.ENV_APP_ID, golatch.ENV_SECRET_KEY, golatch.ENV_USER_ID, golatch.ENV_USER_SECRET_KEY, golatch.ENV_API_URL, golatch.ENV_API_VERSION, golatch.ENV_PROXY, golatch.ENV_TIMEOUT, golatch.ENV_PROFILE, golatch.ENV_CONFIG_FILE} {
+		t.Setenv(name, env[name])
+	}
+	t.Setenv("HOME", t.TempDir())
+}
+
+//Runs the command line returning the exit code and the output
+func (c *testCLI) run(args ...string) (code int, stdout string, stderr string) {
+	var out, err bytes.Buffer
+	code = run(args, &out, &err)
+	return code, out.String(), err.String()
+}
+
+func TestPairLockAndStatus(t *testing.T) {
+	cli := newTestCLI(t)
+	defer cli.server.Close()
+	cli.server.AddPairingToken("MyToken")
+
+	code, stdout, stderr := cli.run("pair", "MyToken")
+	if code != EXIT_OK {
+		t.Fatalf("pair failed: expected exit code %d, got %d (%s)", EXIT_OK, code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 || lines[0] != "ACCOUNT ID" {
+		t.Fatalf("pair failed: unexpected output %q", stdout)
+	}
+	accountId := strings.TrimSpace(lines[1])
+
+	if code, stdout, _ := cli.run("status", accountId); code != EXIT_OK || !strings.Contains(stdout, "MyAppID") || !strings.Contains(stdout, golatch.LATCH_STATUS_ON) {
+		t.Errorf("status failed: expected latch on, got exit code %d and output %q", code, stdout)
+	}
+	if code, _, stderr := cli.run("lock", accountId); code != EXIT_OK {
+		t.Errorf("lock failed: expected exit code %d, got %d (%s)", EXIT_OK, code, stderr)
+	}
+	if code, stdout, _ := cli.run("--json", "status", accountId, "--nootp"); code != EXIT_LATCH_OFF {
+		t.Errorf("status failed: expected exit code %d for a locked account, got %d (%s)", EXIT_LATCH_OFF, code, stdout)
+	} else {
+		var response golatch.LatchStatusResponse
+		if err := json.Unmarshal([]byte(stdout), &response); err != nil || response.Status() != golatch.LATCH_STATUS_OFF {
+			t.Errorf("status failed: unexpected JSON output %q (error %v)", stdout, err)
+		}
+	}
+	if code, _, _ := cli.run("unlock", accountId); code != EXIT_OK || cli.server.Status("MyAppID", accountId) != golatch.LATCH_STATUS_ON {
+		t.Errorf("unlock failed: expected account to be unlocked (exit code %d)", code)
+	}
+	if code, _, _ := cli.run("unpair", accountId); code != EXIT_OK {
+		t.Errorf("unpair failed: expected exit code %d, got %d", EXIT_OK, code)
+	}
+	if code, _, stderr := cli.run("status", accountId); code != EXIT_ERROR || !strings.Contains(stderr, "201") {
+		t.Errorf("status failed: expected exit code %d with the Latch error, got %d (%s)", EXIT_ERROR, code, stderr)
+	}
+}
+
+func TestOperations(t *testing.T) {
+	cli := newTestCLI(t)
+	defer cli.server.Close()
+
+	code, stdout, stderr := cli.run("--json", "operation", "add", "--name", "Transfers", "--two-factor", golatch.MANDATORY)
+	if code != EXIT_OK {
+		t.Fatalf("operation add failed: expected exit code %d, got %d (%s)", EXIT_OK, code, stderr)
+	}
+	var added golatch.LatchAddOperationResponse
+	if err := json.Unmarshal([]byte(stdout), &added); err != nil || added.OperationId() == "" {
+		t.Fatalf("operation add failed: unexpected JSON output %q (error %v)", stdout, err)
+	}
+
+	if code, _, _ := cli.run("operation", "update", added.OperationId(), "--name", "Payments"); code != EXIT_OK {
+		t.Errorf("operation update failed: expected exit code %d, got %d", EXIT_OK, code)
+	}
+	code, stdout, _ = cli.run("operation", "show")
+	if code != EXIT_OK || !strings.Contains(stdout, added.OperationId()) || !strings.Contains(stdout, "Payments") || !strings.Contains(stdout, golatch.MANDATORY) {
+		t.Errorf("operation show failed: unexpected output %q (exit code %d)", stdout, code)
+	}
+	if code, _, _ := cli.run("operation", "delete", added.OperationId()); code != EXIT_OK {
+		t.Errorf("operation delete failed: expected exit code %d, got %d", EXIT_OK, code)
+	}
+	if code, stdout, _ := cli.run("operation", "show"); code != EXIT_OK || strings.Contains(stdout, added.OperationId()) {
+		t.Errorf("operation delete failed: expected operation to be deleted, got %q", stdout)
+	}
+}
+
+func TestInstances(t *testing.T) {
+	cli := newTestCLI(t)
+	defer cli.server.Close()
+	cli.server.PairAccount("MyAppID", "MyAccountID")
+
+	code, stdout, stderr := cli.run("--json", "instance", "add", "MyAccountID", "Laptop")
+	if code != EXIT_OK {
+		t.Fatalf("instance add failed: expected exit code %d, got %d (%s)", EXIT_OK, code, stderr)
+	}
+	var added golatch.LatchAddInstanceResponse
+	if err := json.Unmarshal([]byte(stdout), &added); err != nil || added.InstanceId() == "" {
+		t.Fatalf("instance add failed: unexpected JSON output %q (error %v)", stdout, err)
+	}
+	instanceId := added.InstanceId()
+
+	if code, _, _ := cli.run("instance", "update", "MyAccountID", instanceId, "--name", "Phone"); code != EXIT_OK {
+		t.Errorf("instance update failed: expected exit code %d, got %d", EXIT_OK, code)
+	}
+	if code, stdout, _ := cli.run("instance", "list", "MyAccountID"); code != EXIT_OK || !strings.Contains(stdout, instanceId) || !strings.Contains(stdout, "Phone") {
+		t.Errorf("instance list failed: unexpected output %q (exit code %d)", stdout, code)
+	}
+	if code, _, _ := cli.run("lock", "MyAccountID", "--instance", instanceId); code != EXIT_OK {
+		t.Errorf("lock failed: expected exit code %d, got %d", EXIT_OK, code)
+	}
+	if code, stdout, _ := cli.run("status", "MyAccountID", "--instance", instanceId); code != EXIT_LATCH_OFF || !strings.Contains(stdout, instanceId) {
+		t.Errorf("status failed: expected exit code %d for a locked instance, got %d (%s)", EXIT_LATCH_OFF, code, stdout)
+	}
+	if code, _, _ := cli.run("instance", "delete", "MyAccountID", instanceId); code != EXIT_OK || cli.server.Instance("MyAppID", "MyAccountID", instanceId) != nil {
+		t.Errorf("instance delete failed: expected instance to be deleted (exit code %d)", code)
+	}
+	if code, _, _ := cli.run("instance", "add", "MyAccountID"); code != EXIT_USAGE {
+		t.Errorf("instance add failed: expected exit code %d without names, got %d", EXIT_USAGE, code)
+	}
+}
+
+func TestTotps(t *testing.T) {
+	cli := newTestCLI(t)
+	defer cli.server.Close()
+
+	code, stdout, stderr := cli.run("--json", "totp", "create", "MyUserID", "me@example.com")
+	if code != EXIT_OK {
+		t.Fatalf("totp create failed: expected exit code %d, got %d (%s)", EXIT_OK, code, stderr)
+	}
+	var created golatch.LatchTotpResponse
+	if err := json.Unmarshal([]byte(stdout), &created); err != nil || created.TotpId() == "" {
+		t.Fatalf("totp create failed: unexpected JSON output %q (error %v)", stdout, err)
+	}
+	totpId := created.TotpId()
+
+	if code, stdout, _ := cli.run("totp", "show", totpId); code != EXIT_OK || !strings.Contains(stdout, "otpauth://totp/") {
+		t.Errorf("totp show failed: unexpected output %q (exit code %d)", stdout, code)
+	}
+	if code, _, stderr := cli.run("totp", "validate", totpId, cli.server.TotpCode("MyAppID", totpId, time.Now())); code != EXIT_OK {
+		t.Errorf("totp validate failed: expected exit code %d for a valid code, got %d (%s)", EXIT_OK, code, stderr)
+	}
+	if code, _, stderr := cli.run("totp", "validate", totpId, "000"); code != EXIT_ERROR || !strings.Contains(stderr, "Invalid TOTP code") {
+		t.Errorf("totp validate failed: expected exit code %d for an invalid code, got %d (%s)", EXIT_ERROR, code, stderr)
+	}
+	if code, _, _ := cli.run("totp", "delete", totpId); code != EXIT_OK {
+		t.Errorf("totp delete failed: expected exit code %d, got %d", EXIT_OK, code)
+	}
+}
+
+func TestApplicationsAndSubscription(t *testing.T) {
+	cli := newTestCLI(t)
+	defer cli.server.Close()
+
+	code, stdout, stderr := cli.run("application", "add", "--name", "My Other Application", "--contact-email", "me@example.com", "--two-factor", golatch.MANDATORY)
+	if code != EXIT_OK {
+		t.Fatalf("application add failed: expected exit code %d, got %d (%s)", EXIT_OK, code, stderr)
+	}
+	appID := strings.Fields(strings.Split(strings.TrimSpace(stdout), "\n")[1])[0]
+
+	if code, _, _ := cli.run("application", "update", appID, "--name", "Renamed"); code != EXIT_OK || cli.server.Application(appID).Name != "Renamed" {
+		t.Errorf("application update failed: expected application to be renamed (exit code %d)", code)
+	}
+	if app := cli.server.Application(appID); app.ContactEmail != "me@example.com" || app.TwoFactor != golatch.MANDATORY || app.LockOnRequest != golatch.DISABLED {
+		t.Errorf("application update failed: expected the flags not provided to be left as they were, got %+v", app)
+	}
+	if code, stdout, _ := cli.run("application", "list"); code != EXIT_OK || !strings.Contains(stdout, appID) || !strings.Contains(stdout, "Renamed") {
+		t.Errorf("application list failed: unexpected output %q (exit code %d)", stdout, code)
+	}
+	if code, stdout, _ := cli.run("subscription"); code != EXIT_OK || !strings.Contains(stdout, "applications") {
+		t.Errorf("subscription failed: unexpected output %q (exit code %d)", stdout, code)
+	}
+	if code, _, _ := cli.run("application", "delete", appID); code != EXIT_OK || cli.server.Application(appID) != nil {
+		t.Errorf("application delete failed: expected application to be deleted (exit code %d)", code)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	cli := newTestCLI(t)
+	defer cli.server.Close()
+	cli.server.PairAccount("MyAppID", "MyAccountID")
+
+	if code, _, stderr := cli.run("--header", "X-11Paths-Session=MySession", "status", "MyAccountID", "--header", "X-11Paths-Ip=127.0.0.1"); code != EXIT_OK {
+		t.Fatalf("status failed: expected exit code %d, got %d (%s)", EXIT_OK, code, stderr)
+	}
+	call, _ := cli.server.LastCall()
+	if !call.Verified || len(call.Header["X-11paths-Session"]) != 1 || len(call.Header["X-11paths-Ip"]) != 1 {
+		t.Errorf("status failed: expected signed request with the extra headers, got %v", call)
+	}
+	if code, _, _ := cli.run("status", "MyAccountID", "--header", "X-Session=MySession"); code != EXIT_USAGE {
+		t.Errorf("status failed: expected exit code %d for a header without the X-11Paths- prefix, got %d", EXIT_USAGE, code)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	cli := newTestCLI(t)
+	defer cli.server.Close()
+	cli.server.PairAccount("MyAppID", "MyAccountID")
+	cli.server.AddHistoryEntry("MyAppID", "MyAccountID", golatch.LatchHistoryEntry{Time: 1420070400000, Action: "USER_UPDATE", What: "status", Value: "off", Was: "on"})
+
+	code, stdout, stderr := cli.run("history", "MyAccountID", "--from", "2014-12-01", "--to", "2015-01-31T00:00:00Z")
+	if code != EXIT_OK || !strings.Contains(stdout, "2015-01-01T00:00:00Z") || !strings.Contains(stdout, "USER_UPDATE") {
+		t.Errorf("history failed: unexpected output %q (exit code %d, %s)", stdout, code, stderr)
+	}
+	if code, _, _ := cli.run("history", "MyAccountID", "--from", "yesterday"); code != EXIT_USAGE {
+		t.Errorf("history failed: expected exit code %d for an invalid date, got %d", EXIT_USAGE, code)
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	cli := newTestCLI(t)
+	defer cli.server.Close()
+	cli.server.PairAccount("MyAppID", "MyAccountID")
+
+	//Flags take precedence over the environment
+	if code, _, stderr := cli.run("status", "MyAccountID", "--secret", "MyWrongSecretKey"); code != EXIT_ERROR {
+		t.Errorf("status failed: expected the --secret flag to be used, got exit code %d (%s)", code, stderr)
+	}
+
+	//Config file
+	config := filepath.Join(t.TempDir(), "config.json")
+	data, _ := json.Marshal(map[string]interface{}{"profiles": map[string]interface{}{
+		"staging": map[string]string{"app_id": "MyAppID", "secret_key": "MySecretKey", "api_url": cli.server.URL},
+	}})
+	if err := os.WriteFile(config, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	setTestEnv(t, nil)
+	if code, _, stderr := cli.run("--config", config, "--profile", "staging", "status", "MyAccountID"); code != EXIT_OK {
+		t.Errorf("status failed: expected credentials to be read from the config file, got exit code %d (%s)", code, stderr)
+	}
+	setTestEnv(t, map[string]string{golatch.ENV_CONFIG_FILE: config, golatch.ENV_PROFILE: "staging"})
+	if code, _, stderr := cli.run("status", "MyAccountID"); code != EXIT_OK {
+		t.Errorf("status failed: expected credentials to be read from %s, got exit code %d (%s)", golatch.ENV_CONFIG_FILE, code, stderr)
+	}
+	if code, _, stderr := cli.run("--profile", "prod", "status", "MyAccountID"); code != EXIT_ERROR || !strings.Contains(stderr, "profile not found") {
+		t.Errorf("status failed: expected exit code %d for a missing profile, got %d (%s)", EXIT_ERROR, code, stderr)
+	}
+	if code, _, _ := cli.run("--config", filepath.Join(t.TempDir(), "missing.json"), "status", "MyAccountID"); code != EXIT_ERROR {
+		t.Errorf("status failed: expected exit code %d for a missing config file, got %d", EXIT_ERROR, code)
+	}
+
+	setTestEnv(t, nil)
+	if code, _, stderr := cli.run("status", "MyAccountID"); code != EXIT_USAGE || !strings.Contains(stderr, "missing application credentials") {
+		t.Errorf("status failed: expected exit code %d for missing credentials, got %d (%s)", EXIT_USAGE, code, stderr)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	cli := newTestCLI(t)
+	defer cli.server.Close()
+
+	for _, args := range [][]string{{}, {"unknown"}, {"status"}, {"status", "a", "b"}, {"operation"}, {"operation", "unknown"}, {"lock", "--unknown", "MyAccountID"}, {"operation", "add"}} {
+		if code, _, _ := cli.run(args...); code != EXIT_USAGE {
+			t.Errorf("%v failed: expected exit code %d, got %d", args, EXIT_USAGE, code)
+		}
+	}
+	if code, _, stderr := cli.run("help"); code != EXIT_OK || !strings.Contains(stderr, "subscription") {
+		t.Errorf("help failed: expected list of commands, got %q (exit code %d)", stderr, code)
+	}
+	if code, _, _ := cli.run("status", "-h"); code != EXIT_OK {
+		t.Errorf("status -h failed: expected exit code %d, got %d", EXIT_OK, code)
+	}
+}
@@ -15,6 +15,8 @@ const (
 	API_OPERATION_ACTION                     = "operation"
 	API_APPLICATION_ACTION                   = "application"
 	API_SUBSCRIPTION_ACTION                  = "subscription"
+	API_INSTANCE_ACTION                      = "instance"
+	API_TOTP_ACTION                          = "totps"
 	API_NOOTP_SUFFIX                         = "nootp"
 	API_SILENT_SUFFIX                        = "silent"
 	API_AUTHENTICATION_METHOD                = "11PATHS"
@@ -26,7 +28,7 @@ const (
 	API_UTC_STRING_FORMAT                    = "2006-01-02 15:04:05" //format layout as defined here: http://golang.org/pkg/time/#pkg-constants
 
 	//Possible values for the Two factor and Lock on request options
-	//NOT_SET is used in the UpdateOperation() method to leave the existing value
+	//NOT_SET is used in the UpdateOperation() and UpdateApplication() methods to leave the existing value
 	MANDATORY = "MANDATORY"
 	OPT_IN    = "OPT_IN"
 	DISABLED  = "DISABLED"
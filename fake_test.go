@@ -0,0 +1,42 @@
+package golatch_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/millenc/golatch"
+	"github.com/millenc/golatch/golatchtest"
+)
+
+//The tests of this package that talk to the API use the fake server of golatchtest (it can't be imported by the tests of
+//the golatch package itself, since golatchtest imports it)
+
+//Fake Latch API with an application (MyAppID) with an operation (MyOperationID) and a paired account (MyAccountID) whose latches are on
+func newFakeServer() *golatchtest.Server {
+	server := golatchtest.NewServer()
+	server.AddApplication("", "MyAppID", "MySecretKey", "My Application")
+	server.AddOperation("MyAppID", "MyAppID", "MyOperationID", "My Operation")
+	server.PairAccount("MyAppID", "MyAccountID")
+	return server
+}
+
+//Serves the fake Latch API calling before() first with each request, which can delay it or answer it itself (returning false)
+func newFrontServer(server *golatchtest.Server, before func(w http.ResponseWriter, r *http.Request) bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if before(w, r) {
+			server.ServeHTTP(w, r)
+		}
+	}))
+}
+
+//Returns a client of MyAppID that sends its requests to the front server provided
+func newFrontLatch(server *golatchtest.Server, front *httptest.Server) *golatch.Latch {
+	latch := server.Latch("MyAppID")
+	latch.SetAPIURL(front.URL)
+	return latch
+}
+
+//Gets the number of status requests received by the fake server
+func statusCalls(server *golatchtest.Server) int {
+	return len(server.CallsTo(golatch.API_CHECK_STATUS_ACTION))
+}
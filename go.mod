@@ -0,0 +1,3 @@
+module github.com/millenc/golatch
+
+go 1.21
@@ -0,0 +1,787 @@
+//Package golatchtest provides an in-process fake Latch server to test code that uses golatch without calling the real service
+package golatchtest
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/millenc/golatch"
+)
+
+//Period of the TOTPs (in seconds) and number of periods that codes are reported as expired (instead of invalid) by the server
+const (
+	TOTP_PERIOD          = 30
+	TOTP_EXPIRED_PERIODS = 10
+)
+
+//Fake Latch server implementing the API (all the versions registered in golatch) with in-memory state
+//Every request must be signed with the credentials of an application or user added to the server
+type Server struct {
+	*httptest.Server
+	//Max difference allowed between the date of the requests and the current time (0 disables the check)
+	MaxSkew time.Duration
+
+	mu     sync.Mutex
+	apps   map[string]*Application
+	users  map[string]*User
+	tokens map[string]bool
+	calls  []Call
+	now    func() time.Time
+}
+
+//Starts a new fake server. Call Close() when you are done with it
+func NewServer() *Server {
+	s := &Server{
+		apps:   make(map[string]*Application),
+		users:  make(map[string]*User),
+		tokens: make(map[string]bool),
+		now:    time.Now,
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+//Returns a Latch client for an application of the server
+func (s *Server) Latch(appID string) *golatch.Latch {
+	s.mu.Lock()
+	secret := s.apps[appID].Secret
+	s.mu.Unlock()
+
+	latch := golatch.NewLatch(appID, secret)
+	latch.SetAPIURL(s.URL)
+	return latch
+}
+
+//Returns a LatchUser client for a user of the server
+func (s *Server) LatchUser(userID string) *golatch.LatchUser {
+	s.mu.Lock()
+	secret := s.users[userID].Secret
+	s.mu.Unlock()
+
+	latch := golatch.NewLatchUser(userID, secret)
+	latch.SetAPIURL(s.URL)
+	return latch
+}
+
+//Implementation of the http.Handler interface
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version, segments, ok := parsePath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	r.ParseForm()
+	call := Call{HttpMethod: r.Method, Path: r.URL.Path, Action: segments[0], Version: version, Params: r.PostForm, Header: r.Header}
+
+	var data interface{}
+	var err *golatch.LatchError
+	switch call.Action {
+	case golatch.API_SUBSCRIPTION_ACTION, golatch.API_APPLICATION_ACTION:
+		var user *User
+		if user, call.ID, call.Verified = s.verifyUser(r); call.Verified {
+			data, err = s.handleUser(r, user, segments)
+		}
+	default:
+		var app *Application
+		if app, call.ID, call.Verified = s.verifyApplication(r); call.Verified {
+			data, err = s.handleApplication(r, app, segments)
+		}
+	}
+	s.calls = append(s.calls, call)
+
+	if !call.Verified {
+		err = golatch.ErrInvalidSignature
+	}
+	writeResponse(w, data, err)
+}
+
+//Gets the version and the segments of the path of a request (starting with the action). The version must be registered in golatch
+//and the action available in that version
+func parsePath(path string) (version string, segments []string, ok bool) {
+	prefix := golatch.API_PATH + "/"
+	if !strings.HasPrefix(path, prefix) {
+		return "", nil, false
+	}
+	segments = strings.Split(strings.TrimPrefix(path, prefix), "/")
+	if len(segments) < 2 {
+		return "", nil, false
+	}
+
+	version, segments = segments[0], segments[1:]
+	spec, ok := golatch.GetAPIVersionSpec(version)
+	if !ok {
+		return "", nil, false
+	}
+	for action, actionPath := range spec.Actions {
+		if actionPath == segments[0] {
+			segments[0] = action
+			return version, segments, true
+		}
+	}
+	return "", nil, false
+}
+
+//Verifies the signature of a request made with the credentials of an application
+func (s *Server) verifyApplication(r *http.Request) (app *Application, id string, ok bool) {
+	id, err := golatch.NewLatchVerifier(func(id string) (string, bool) {
+		if app, ok = s.apps[id]; ok {
+			return app.Secret, true
+		}
+		return "", false
+	}, s.MaxSkew).Verify(r)
+	return app, id, err == nil
+}
+
+//Verifies the signature of a request made with the credentials of a user
+func (s *Server) verifyUser(r *http.Request) (user *User, id string, ok bool) {
+	id, err := golatch.NewLatchVerifier(func(id string) (string, bool) {
+		if user, ok = s.users[id]; ok {
+			return user.Secret, true
+		}
+		return "", false
+	}, s.MaxSkew).Verify(r)
+	return user, id, err == nil
+}
+
+//Writes a response (or an error) like the Latch API does
+func writeResponse(w http.ResponseWriter, data interface{}, err *golatch.LatchError) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": err})
+	} else if data != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	} else {
+		w.Write([]byte("{}"))
+	}
+}
+
+//Handles the requests of the application API (must be called with the lock held)
+func (s *Server) handleApplication(r *http.Request, app *Application, segments []string) (interface{}, *golatch.LatchError) {
+	switch segments[0] {
+	case golatch.API_PAIR_ACTION:
+		return s.handlePair(app, segments)
+	case golatch.API_PAIR_WITH_ID_ACTION:
+		return s.handlePairWithId(app, segments)
+	case golatch.API_UNPAIR_ACTION:
+		if _, err := pairedAccount(app, segments); err != nil {
+			return nil, err
+		}
+		delete(app.Accounts, segments[1])
+		return nil, nil
+	case golatch.API_CHECK_STATUS_ACTION:
+		return s.handleStatus(r, app, segments)
+	case golatch.API_LOCK_ACTION:
+		return s.handleLock(app, segments, golatch.LATCH_STATUS_OFF)
+	case golatch.API_UNLOCK_ACTION:
+		return s.handleLock(app, segments, golatch.LATCH_STATUS_ON)
+	case golatch.API_HISTORY_ACTION:
+		return s.handleHistory(app, segments)
+	case golatch.API_OPERATION_ACTION:
+		return s.handleOperation(r, app, segments)
+	case golatch.API_INSTANCE_ACTION:
+		return s.handleInstance(r, app, segments)
+	case golatch.API_TOTP_ACTION:
+		return s.handleTotp(r, app, segments)
+	}
+
+	return nil, golatch.ErrBadRequest
+}
+
+func (s *Server) handlePair(app *Application, segments []string) (interface{}, *golatch.LatchError) {
+	if len(segments) < 2 || !s.tokens[segments[1]] {
+		return nil, golatch.ErrInvalidToken
+	}
+	if err := s.checkUsersLimit(app); err != nil {
+		return nil, err
+	}
+
+	delete(s.tokens, segments[1])
+	account := s.pair(app, randomID(20))
+	return map[string]string{"accountId": account.ID}, nil
+}
+
+func (s *Server) handlePairWithId(app *Application, segments []string) (interface{}, *golatch.LatchError) {
+	if len(segments) < 2 || segments[1] == "" {
+		return nil, golatch.ErrMissingParameter
+	}
+	if _, ok := app.Accounts[segments[1]]; ok {
+		return nil, golatch.ErrAlreadyPaired
+	}
+	if err := s.checkUsersLimit(app); err != nil {
+		return nil, err
+	}
+
+	account := s.pair(app, segments[1])
+	return map[string]string{"accountId": account.ID}, nil
+}
+
+//Status of an application or operation in status responses
+type statusJSON struct {
+	Status     string                  `json:"status"`
+	TwoFactor  *golatch.LatchTwoFactor `json:"two_factor,omitempty"`
+	Operations map[string]statusJSON   `json:"operations,omitempty"`
+}
+
+//Handles status/{accountId}[/op/{operationId}][/i/{instanceId}][/nootp][/silent]
+func (s *Server) handleStatus(r *http.Request, app *Application, segments []string) (interface{}, *golatch.LatchError) {
+	account, err := pairedAccount(app, segments)
+	if err != nil {
+		return nil, err
+	}
+	operation, instance, err := target(app, account, segments)
+	if err != nil {
+		return nil, err
+	}
+
+	id, name, twoFactor := app.ID, app.Name, app.TwoFactor
+	if operation != nil {
+		id, name, twoFactor = operation.ID, operation.Name, operation.TwoFactor
+	}
+	nootp := false
+	for _, segment := range segments[2:] {
+		if segment == golatch.API_NOOTP_SUFFIX {
+			nootp = true
+		}
+	}
+
+	var status statusJSON
+	if instance != nil {
+		status = statusJSON{Status: effectiveStatus(app, account, id)}
+		if status.Status == golatch.LATCH_STATUS_ON {
+			status.Status = statusOrOn(instance.Status)
+		}
+		id, name = instance.ID, instance.Name
+	} else {
+		status = s.statusTree(app, account, id)
+	}
+	if !nootp && twoFactor == golatch.MANDATORY && status.Status == golatch.LATCH_STATUS_ON {
+		status.TwoFactor = &golatch.LatchTwoFactor{Token: strings.ToUpper(randomID(6)), Generated: millis(s.now())}
+	}
+
+	account.History = append(account.History, golatch.LatchHistoryEntry{
+		Time:      millis(s.now()),
+		Action:    "get",
+		What:      "status",
+		Value:     status.Status,
+		Name:      name,
+		UserAgent: r.UserAgent(),
+		IP:        remoteIP(r),
+	})
+
+	return map[string]interface{}{"operations": map[string]statusJSON{id: status}}, nil
+}
+
+//Gets the operation and the instance of a request from segments[2:] ([/op/{operationId}][/i/{instanceId}])
+//Both are nil if they are not in the path. The instance must belong to the account and the operation
+func target(app *Application, account *Account, segments []string) (operation *Operation, instance *Instance, err *golatch.LatchError) {
+	for i := 2; i < len(segments); i++ {
+		switch segments[i] {
+		case "op":
+			if i+1 >= len(segments) || app.Operations[segments[i+1]] == nil {
+				return nil, nil, golatch.ErrOperationNotFound
+			}
+			operation = app.Operations[segments[i+1]]
+			i++
+		case "i":
+			if i+1 >= len(segments) || account.Instances[segments[i+1]] == nil {
+				return nil, nil, golatch.ErrInvalidParameter
+			}
+			instance = account.Instances[segments[i+1]]
+			i++
+		}
+	}
+
+	if instance != nil && (operation == nil && instance.OperationID != "" || operation != nil && instance.OperationID != operation.ID) {
+		return nil, nil, golatch.ErrInvalidParameter
+	}
+	return operation, instance, nil
+}
+
+//Builds the status of an application or operation and its children
+func (s *Server) statusTree(app *Application, account *Account, id string) statusJSON {
+	status := statusJSON{Status: effectiveStatus(app, account, id)}
+	for _, child := range app.children(id) {
+		if status.Operations == nil {
+			status.Operations = make(map[string]statusJSON)
+		}
+		status.Operations[child.ID] = s.statusTree(app, account, child.ID)
+	}
+	return status
+}
+
+//Gets the status of an application or operation taking into account the status of its parents
+func effectiveStatus(app *Application, account *Account, id string) string {
+	for id != app.ID {
+		operation, ok := app.Operations[id]
+		if !ok || statusOrOn(account.Operations[id]) == golatch.LATCH_STATUS_OFF {
+			return golatch.LATCH_STATUS_OFF
+		}
+		id = operation.ParentID
+	}
+	return statusOrOn(account.Status)
+}
+
+//Handles lock|unlock/{accountId}[/op/{operationId}][/i/{instanceId}]
+func (s *Server) handleLock(app *Application, segments []string, status string) (interface{}, *golatch.LatchError) {
+	account, err := pairedAccount(app, segments)
+	if err != nil {
+		return nil, err
+	}
+	operation, instance, err := target(app, account, segments)
+	if err != nil {
+		return nil, err
+	}
+
+	name, was := app.Name, account.Status
+	switch {
+	case instance != nil:
+		name, was = instance.Name, statusOrOn(instance.Status)
+		instance.Status = status
+	case operation != nil:
+		name, was = operation.Name, statusOrOn(account.Operations[operation.ID])
+		account.Operations[operation.ID] = status
+	default:
+		account.Status = status
+	}
+
+	account.History = append(account.History, golatch.LatchHistoryEntry{
+		Time:   millis(s.now()),
+		Action: "DEVELOPER_UPDATE",
+		What:   "status",
+		Was:    was,
+		Value:  status,
+		Name:   name,
+	})
+	return nil, nil
+}
+
+//Handles history/{accountId}[/{from}[/{to}]]
+func (s *Server) handleHistory(app *Application, segments []string) (interface{}, *golatch.LatchError) {
+	account, err := pairedAccount(app, segments)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := int64(0), millis(s.now())
+	if len(segments) > 2 {
+		if from, err = parseMillis(segments[2]); err != nil {
+			return nil, err
+		}
+	}
+	if len(segments) > 3 {
+		if to, err = parseMillis(segments[3]); err != nil {
+			return nil, err
+		}
+	}
+
+	history := []golatch.LatchHistoryEntry{}
+	for _, entry := range account.History {
+		if entry.Time >= from && entry.Time <= to {
+			history = append(history, entry)
+		}
+	}
+
+	application, _ := json.Marshal(golatch.LatchApplication{
+		Status:   account.Status,
+		PairedOn: account.PairedOn,
+		LatchApplicationInfo: golatch.LatchApplicationInfo{
+			Name:          app.Name,
+			Description:   app.Description,
+			ImageURL:      app.ImageURL,
+			ContactPhone:  app.ContactPhone,
+			ContactEmail:  app.ContactEmail,
+			TwoFactor:     app.TwoFactor,
+			LockOnRequest: app.LockOnRequest,
+			Operations:    operationTree(app, app.ID, account),
+		},
+	})
+	rest, _ := json.Marshal(struct {
+		LastSeen      int64                        `json:"lastSeen"`
+		ClientVersion []golatch.LatchClientVersion `json:"clientVersion"`
+		Count         int                          `json:"count"`
+		History       []golatch.LatchHistoryEntry  `json:"history"`
+	}{account.LastSeen, []golatch.LatchClientVersion{}, len(history), history})
+
+	//The application information is keyed by application ID and must come first
+	return json.RawMessage(fmt.Sprintf("{%q:%s,%s", app.ID, application, rest[1:])), nil
+}
+
+//Handles the operation endpoints (show, add, update and delete)
+func (s *Server) handleOperation(r *http.Request, app *Application, segments []string) (interface{}, *golatch.LatchError) {
+	var operation *Operation
+	if len(segments) > 1 {
+		if operation = app.Operations[segments[1]]; operation == nil {
+			return nil, golatch.ErrOperationNotFound
+		}
+	}
+
+	switch {
+	case r.Method == golatch.HTTP_METHOD_GET && operation == nil:
+		return map[string]interface{}{"operations": operationTree(app, app.ID, nil)}, nil
+	case r.Method == golatch.HTTP_METHOD_GET:
+		return map[string]interface{}{"operations": map[string]golatch.LatchOperation{operation.ID: operationInfo(app, operation, nil)}}, nil
+	case r.Method == golatch.HTTP_METHOD_PUT && operation == nil:
+		parentID, name := r.PostForm.Get("parentId"), r.PostForm.Get("name")
+		if parentID == "" || name == "" {
+			return nil, golatch.ErrMissingParameter
+		}
+		if parentID != app.ID && app.Operations[parentID] == nil {
+			return nil, golatch.ErrInvalidParameter
+		}
+		if owner := s.users[app.Owner]; owner != nil && exceeds(len(app.Operations), owner.OperationsLimit) {
+			return nil, limitExceeded("operations")
+		}
+
+		operation = &Operation{ID: randomID(20), ParentID: parentID, Name: name, TwoFactor: golatch.DISABLED, LockOnRequest: golatch.DISABLED}
+		updateOperation(operation, r)
+		app.Operations[operation.ID] = operation
+		return map[string]string{"operationId": operation.ID}, nil
+	case r.Method == golatch.HTTP_METHOD_POST && operation != nil:
+		updateOperation(operation, r)
+		return nil, nil
+	case r.Method == golatch.HTTP_METHOD_DELETE && operation != nil:
+		deleteOperation(app, operation.ID)
+		return nil, nil
+	}
+
+	return nil, golatch.ErrBadRequest
+}
+
+//Updates the non empty values of an operation with the request parameters
+func updateOperation(operation *Operation, r *http.Request) {
+	if name := r.PostForm.Get("name"); name != "" {
+		operation.Name = name
+	}
+	if twoFactor := r.PostForm.Get("two_factor"); twoFactor != "" {
+		operation.TwoFactor = twoFactor
+	}
+	if lockOnRequest := r.PostForm.Get("lock_on_request"); lockOnRequest != "" {
+		operation.LockOnRequest = lockOnRequest
+	}
+}
+
+//Deletes an operation and all its children
+func deleteOperation(app *Application, operationID string) {
+	for _, child := range app.children(operationID) {
+		deleteOperation(app, child.ID)
+	}
+	delete(app.Operations, operationID)
+}
+
+//Builds the information of the operations whose parent is the ID provided (including the status if account is not nil)
+func operationTree(app *Application, parentID string, account *Account) map[string]golatch.LatchOperation {
+	operations := make(map[string]golatch.LatchOperation)
+	for _, child := range app.children(parentID) {
+		operations[child.ID] = operationInfo(app, child, account)
+	}
+	return operations
+}
+
+func operationInfo(app *Application, operation *Operation, account *Account) golatch.LatchOperation {
+	info := golatch.LatchOperation{
+		Name:          operation.Name,
+		TwoFactor:     operation.TwoFactor,
+		LockOnRequest: operation.LockOnRequest,
+		Operations:    operationTree(app, operation.ID, account),
+	}
+	if account != nil {
+		info.Status = statusOrOn(account.Operations[operation.ID])
+	}
+	return info
+}
+
+//Handles the instance endpoints: instance/{accountId}[/op/{operationId}][/i/{instanceId}] (list, add, rename and delete)
+func (s *Server) handleInstance(r *http.Request, app *Application, segments []string) (interface{}, *golatch.LatchError) {
+	account, err := pairedAccount(app, segments)
+	if err != nil {
+		return nil, err
+	}
+	operation, instance, err := target(app, account, segments)
+	if err != nil {
+		return nil, err
+	}
+	operationID := ""
+	if operation != nil {
+		operationID = operation.ID
+	}
+
+	switch {
+	case r.Method == golatch.HTTP_METHOD_GET && instance == nil:
+		instances := make(map[string]golatch.LatchInstance)
+		for _, instance := range account.Instances {
+			if instance.OperationID == operationID {
+				instances[instance.ID] = golatch.LatchInstance{Name: instance.Name}
+			}
+		}
+		return map[string]interface{}{"instances": instances}, nil
+	case r.Method == golatch.HTTP_METHOD_PUT && instance == nil:
+		names := r.PostForm["instances"]
+		if len(names) == 0 {
+			return nil, golatch.ErrMissingParameter
+		}
+
+		instances := make(map[string]string)
+		for _, name := range names {
+			instance := &Instance{ID: randomID(20), Name: name, OperationID: operationID}
+			account.Instances[instance.ID] = instance
+			instances[instance.ID] = instance.Name
+		}
+		return map[string]interface{}{"instances": instances}, nil
+	case r.Method == golatch.HTTP_METHOD_POST && instance != nil:
+		name := r.PostForm.Get("name")
+		if name == "" {
+			return nil, golatch.ErrMissingParameter
+		}
+		instance.Name = name
+		return nil, nil
+	case r.Method == golatch.HTTP_METHOD_DELETE && instance != nil:
+		delete(account.Instances, instance.ID)
+		return nil, nil
+	}
+
+	return nil, golatch.ErrBadRequest
+}
+
+//Handles the TOTP endpoints: totps[/{totpId}[/validate]] (create, show, validate and delete)
+func (s *Server) handleTotp(r *http.Request, app *Application, segments []string) (interface{}, *golatch.LatchError) {
+	if len(segments) == 1 {
+		if r.Method != golatch.HTTP_METHOD_POST {
+			return nil, golatch.ErrBadRequest
+		}
+		userID, commonName := r.PostForm.Get("userId"), r.PostForm.Get("commonName")
+		if userID == "" || commonName == "" {
+			return nil, golatch.ErrMissingParameter
+		}
+
+		secret := make([]byte, 20)
+		rand.Read(secret)
+		totp := &Totp{
+			ID:         randomID(20),
+			UserID:     userID,
+			CommonName: commonName,
+			Secret:     base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret),
+			CreatedAt:  millis(s.now()),
+		}
+		app.Totps[totp.ID] = totp
+		return totpInfo(app, totp), nil
+	}
+
+	totp, ok := app.Totps[segments[1]]
+	if !ok {
+		return nil, golatch.ErrTotpNotFound
+	}
+	switch {
+	case r.Method == golatch.HTTP_METHOD_GET && len(segments) == 2:
+		return totpInfo(app, totp), nil
+	case r.Method == golatch.HTTP_METHOD_DELETE && len(segments) == 2:
+		delete(app.Totps, totp.ID)
+		return nil, nil
+	case r.Method == golatch.HTTP_METHOD_POST && len(segments) == 3 && segments[2] == "validate":
+		return nil, s.validateTotp(totp, r.PostForm.Get("code"))
+	}
+
+	return nil, golatch.ErrBadRequest
+}
+
+//Validates a TOTP code. Codes of the previous, current and next periods are valid, and codes of the
+//last TOTP_EXPIRED_PERIODS periods are expired
+func (s *Server) validateTotp(totp *Totp, code string) *golatch.LatchError {
+	if code == "" {
+		return golatch.ErrMissingParameter
+	}
+
+	step := s.now().Unix() / TOTP_PERIOD
+	for i := int64(-1); i <= 1; i++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(totp.Secret, step+i)), []byte(code)) == 1 {
+			return nil
+		}
+	}
+	for i := int64(2); i <= TOTP_EXPIRED_PERIODS; i++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(totp.Secret, step-i)), []byte(code)) == 1 {
+			return golatch.ErrTotpCodeExpired
+		}
+	}
+	return golatch.ErrInvalidTotpCode
+}
+
+func totpInfo(app *Application, totp *Totp) golatch.LatchTotp {
+	info := golatch.LatchTotp{
+		TotpId:     totp.ID,
+		Secret:     totp.Secret,
+		AppId:      app.ID,
+		Identity:   totp.CommonName,
+		Issuer:     app.Name,
+		Algorithm:  "SHA1",
+		Digits:     6,
+		Period:     TOTP_PERIOD,
+		CreatedAt:  totp.CreatedAt,
+		DisabledBy: []string{},
+	}
+	info.URI = info.ProvisioningURI()
+	return info
+}
+
+//Handles the requests of the user API (must be called with the lock held)
+func (s *Server) handleUser(r *http.Request, user *User, segments []string) (interface{}, *golatch.LatchError) {
+	if segments[0] == golatch.API_SUBSCRIPTION_ACTION {
+		return s.subscription(user), nil
+	}
+
+	var app *Application
+	if len(segments) > 1 {
+		if app = s.apps[segments[1]]; app == nil || app.Owner != user.ID {
+			return nil, golatch.ErrInvalidParameter
+		}
+	}
+
+	switch {
+	case r.Method == golatch.HTTP_METHOD_GET && app == nil:
+		applications := make(map[string]golatch.LatchApplicationInfo)
+		for _, app := range s.apps {
+			if app.Owner == user.ID {
+				applications[app.ID] = applicationInfo(app)
+			}
+		}
+		return map[string]interface{}{"operations": applications}, nil
+	case r.Method == golatch.HTTP_METHOD_PUT && app == nil:
+		if r.PostForm.Get("name") == "" {
+			return nil, golatch.ErrMissingParameter
+		}
+		if exceeds(len(s.userApplications(user)), user.ApplicationsLimit) {
+			return nil, limitExceeded("applications")
+		}
+
+		app = &Application{ID: randomID(20), Secret: randomID(40), Owner: user.ID, TwoFactor: golatch.DISABLED, LockOnRequest: golatch.DISABLED, Operations: make(map[string]*Operation), Accounts: make(map[string]*Account)}
+		updateApplication(app, r)
+		s.apps[app.ID] = app
+		return map[string]string{"applicationId": app.ID, "secret": app.Secret}, nil
+	case r.Method == golatch.HTTP_METHOD_POST && app != nil:
+		updateApplication(app, r)
+		return nil, nil
+	case r.Method == golatch.HTTP_METHOD_DELETE && app != nil:
+		delete(s.apps, app.ID)
+		return nil, nil
+	}
+
+	return nil, golatch.ErrBadRequest
+}
+
+//Updates the non empty values of an application with the request parameters
+func updateApplication(app *Application, r *http.Request) {
+	for param, value := range map[string]*string{
+		"name":            &app.Name,
+		"contactEmail":    &app.ContactEmail,
+		"contactPhone":    &app.ContactPhone,
+		"two_factor":      &app.TwoFactor,
+		"lock_on_request": &app.LockOnRequest,
+	} {
+		if v := r.PostForm.Get(param); v != "" {
+			*value = v
+		}
+	}
+}
+
+func applicationInfo(app *Application) golatch.LatchApplicationInfo {
+	return golatch.LatchApplicationInfo{
+		Name:          app.Name,
+		Description:   app.Description,
+		Secret:        app.Secret,
+		ImageURL:      app.ImageURL,
+		ContactPhone:  app.ContactPhone,
+		ContactEmail:  app.ContactEmail,
+		TwoFactor:     app.TwoFactor,
+		LockOnRequest: app.LockOnRequest,
+		Operations:    operationTree(app, app.ID, nil),
+	}
+}
+
+//Builds the subscription information of a user
+func (s *Server) subscription(user *User) interface{} {
+	users, operations := 0, make(map[string]golatch.LatchSubscriptionUsage)
+	apps := s.userApplications(user)
+	for _, app := range apps {
+		users += len(app.Accounts)
+		operations[app.Name] = golatch.LatchSubscriptionUsage{InUse: len(app.Operations), Limit: user.OperationsLimit}
+	}
+
+	return map[string]interface{}{"subscription": map[string]interface{}{
+		"id":           user.Subscription,
+		"applications": golatch.LatchSubscriptionUsage{InUse: len(apps), Limit: user.ApplicationsLimit},
+		"operations":   operations,
+		"users":        golatch.LatchSubscriptionUsage{InUse: users, Limit: user.UsersLimit},
+	}}
+}
+
+func (s *Server) userApplications(user *User) (apps []*Application) {
+	for _, app := range s.apps {
+		if app.Owner == user.ID {
+			apps = append(apps, app)
+		}
+	}
+	return apps
+}
+
+//Checks that the owner of an application (if any) can have more paired accounts
+func (s *Server) checkUsersLimit(app *Application) *golatch.LatchError {
+	owner := s.users[app.Owner]
+	if owner == nil {
+		return nil
+	}
+
+	users := 0
+	for _, app := range s.userApplications(owner) {
+		users += len(app.Accounts)
+	}
+	if exceeds(users, owner.UsersLimit) {
+		return limitExceeded("users")
+	}
+	return nil
+}
+
+//Gets the paired account of segments[1]
+func pairedAccount(app *Application, segments []string) (*Account, *golatch.LatchError) {
+	if len(segments) < 2 || segments[1] == "" {
+		return nil, golatch.ErrMissingParameter
+	}
+	if account, ok := app.Accounts[segments[1]]; ok {
+		return account, nil
+	}
+	return nil, golatch.ErrAccountNotPaired
+}
+
+func exceeds(inUse int, limit int) bool {
+	return limit >= 0 && inUse >= limit
+}
+
+func limitExceeded(what string) *golatch.LatchError {
+	return &golatch.LatchError{Code: golatch.ErrSubscriptionRequired.Code, Message: fmt.Sprintf("Subscription limit of %s exceeded", what)}
+}
+
+func parseMillis(value string) (int64, *golatch.LatchError) {
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, golatch.ErrInvalidParameter
+	}
+	return millis, nil
+}
+
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,308 @@
+package golatchtest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/millenc/golatch"
+)
+
+func newTestServer() *Server {
+	server := NewServer()
+	server.AddApplication("", "MyAppID", "MySecretKey", "My Application")
+	return server
+}
+
+func TestPairAndStatus(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	server.AddPairingToken("MyToken")
+	latch := server.Latch("MyAppID")
+
+	response, err := latch.Pair("MyToken")
+	if err != nil {
+		t.Fatalf("Pair() failed: unexpected error %v", err)
+	}
+	accountId := response.AccountId()
+	if _, err := latch.Pair("MyToken"); !errors.Is(err, golatch.ErrInvalidToken) {
+		t.Errorf("Pair() failed: expected tokens to be used only once, got %v", err)
+	}
+
+	if status, err := latch.Status(accountId, false, false); err != nil || status.Status() != golatch.LATCH_STATUS_ON {
+		t.Errorf("Status() failed: expected status on, got %v (error %v)", status, err)
+	}
+	if err := latch.Lock(accountId); err != nil || server.Status("MyAppID", accountId) != golatch.LATCH_STATUS_OFF {
+		t.Errorf("Lock() failed: expected account to be locked (error %v)", err)
+	}
+	if status, err := latch.Status(accountId, true, true); err != nil || status.Status() != golatch.LATCH_STATUS_OFF {
+		t.Errorf("Status() failed: expected status off, got %v (error %v)", status, err)
+	}
+
+	if err := latch.Unpair(accountId); err != nil {
+		t.Errorf("Unpair() failed: unexpected error %v", err)
+	}
+	if _, err := latch.Status(accountId, false, false); !errors.Is(err, golatch.ErrAccountNotPaired) {
+		t.Errorf("Status() failed: expected ErrAccountNotPaired after Unpair(), got %v", err)
+	}
+}
+
+func TestOperations(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	server.PairAccount("MyAppID", "MyAccountID")
+	latch := server.Latch("MyAppID")
+
+	added, err := latch.AddOperation("MyAppID", "Transfers", golatch.MANDATORY, golatch.DISABLED)
+	if err != nil {
+		t.Fatalf("AddOperation() failed: unexpected error %v", err)
+	}
+	child, _ := latch.AddOperation(added.OperationId(), "Big transfers", golatch.DISABLED, golatch.DISABLED)
+
+	if err := latch.UpdateOperation(added.OperationId(), "Payments", golatch.NOT_SET, golatch.OPT_IN); err != nil {
+		t.Errorf("UpdateOperation() failed: unexpected error %v", err)
+	}
+	shown, err := latch.ShowOperation(added.OperationId())
+	if id, operation := shown.FirstOperation(); err != nil || id != added.OperationId() || operation.Name != "Payments" || operation.TwoFactor != golatch.MANDATORY || operation.LockOnRequest != golatch.OPT_IN || len(operation.Operations) != 1 {
+		t.Errorf("ShowOperation() failed: unexpected operation %v (error %v)", operation, err)
+	}
+
+	//Locking the parent operation locks its children
+	if status, err := latch.OperationStatus("MyAccountID", added.OperationId(), false, false); err != nil || status.TwoFactor().Token == "" {
+		t.Errorf("OperationStatus() failed: expected two factor token for a MANDATORY operation, got %v (error %v)", status, err)
+	}
+	latch.LockOperation("MyAccountID", added.OperationId())
+	if status, err := latch.OperationStatus("MyAccountID", child.OperationId(), false, false); err != nil || status.Status() != golatch.LATCH_STATUS_OFF {
+		t.Errorf("OperationStatus() failed: expected child operation to be off, got %v (error %v)", status, err)
+	}
+	if status, _ := latch.Status("MyAccountID", true, false); status.Operations()[added.OperationId()].Status != golatch.LATCH_STATUS_OFF {
+		t.Errorf("Status() failed: expected operation to be off in the application status, got %v", status)
+	}
+
+	if err := latch.DeleteOperation(added.OperationId()); err != nil {
+		t.Errorf("DeleteOperation() failed: unexpected error %v", err)
+	}
+	if _, err := latch.ShowOperation(child.OperationId()); !errors.Is(err, golatch.ErrOperationNotFound) {
+		t.Errorf("DeleteOperation() failed: expected child operations to be deleted, got %v", err)
+	}
+}
+
+func TestInstances(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	server.PairAccount("MyAppID", "MyAccountID")
+	server.AddOperation("MyAppID", "MyAppID", "MyOperationID", "Transfers")
+	latch := server.Latch("MyAppID")
+
+	added, err := latch.AddInstance("MyAccountID", "", "Laptop", "Phone")
+	if err != nil || len(added.Instances()) != 2 {
+		t.Fatalf("AddInstance() failed: expected two instances, got %v (error %v)", added, err)
+	}
+	operationInstance, _ := latch.AddInstance("MyAccountID", "MyOperationID", "Tablet")
+
+	shown, err := latch.ShowInstances("MyAccountID", "")
+	if err != nil || len(shown.Instances()) != 2 {
+		t.Errorf("ShowInstances() failed: expected the instances of the account, got %v (error %v)", shown, err)
+	}
+	if shown, _ := latch.ShowInstances("MyAccountID", "MyOperationID"); len(shown.Instances()) != 1 || shown.Instances()[operationInstance.InstanceId()].Name != "Tablet" {
+		t.Errorf("ShowInstances() failed: expected the instances of the operation, got %v", shown)
+	}
+
+	var instanceId string
+	for id, name := range added.Instances() {
+		if name == "Laptop" {
+			instanceId = id
+		}
+	}
+	if err := latch.UpdateInstance("MyAccountID", "", instanceId, "Work laptop"); err != nil || server.Instance("MyAppID", "MyAccountID", instanceId).Name != "Work laptop" {
+		t.Errorf("UpdateInstance() failed: expected instance to be renamed (error %v)", err)
+	}
+
+	//Instances have their own latch, but are off if their parents are locked
+	if err := latch.LockInstance("MyAccountID", "", instanceId); err != nil || server.Instance("MyAppID", "MyAccountID", instanceId).Status != golatch.LATCH_STATUS_OFF {
+		t.Errorf("LockInstance() failed: expected instance to be locked (error %v)", err)
+	}
+	if status, err := latch.InstanceStatus("MyAccountID", "", instanceId, false, false); err != nil || status.Status() != golatch.LATCH_STATUS_OFF {
+		t.Errorf("InstanceStatus() failed: expected status off, got %v (error %v)", status, err)
+	}
+	if status, _ := latch.Status("MyAccountID", false, false); status.Status() != golatch.LATCH_STATUS_ON {
+		t.Errorf("Status() failed: expected the account not to be locked by its instances, got %v", status)
+	}
+	latch.UnlockInstance("MyAccountID", "", instanceId)
+	latch.LockOperation("MyAccountID", "MyOperationID")
+	if status, err := latch.InstanceStatus("MyAccountID", "MyOperationID", operationInstance.InstanceId(), true, false); err != nil || status.Status() != golatch.LATCH_STATUS_OFF {
+		t.Errorf("InstanceStatus() failed: expected instance of a locked operation to be off, got %v (error %v)", status, err)
+	}
+	if status, err := latch.InstanceStatus("MyAccountID", "", instanceId, true, false); err != nil || status.Status() != golatch.LATCH_STATUS_ON {
+		t.Errorf("InstanceStatus() failed: expected status on, got %v (error %v)", status, err)
+	}
+	if _, err := latch.InstanceStatus("MyAccountID", "", operationInstance.InstanceId(), true, false); !errors.Is(err, golatch.ErrInvalidParameter) {
+		t.Errorf("InstanceStatus() failed: expected ErrInvalidParameter for an instance of another operation, got %v", err)
+	}
+
+	if err := latch.DeleteInstance("MyAccountID", "", instanceId); err != nil || server.Instance("MyAppID", "MyAccountID", instanceId) != nil {
+		t.Errorf("DeleteInstance() failed: expected instance to be deleted (error %v)", err)
+	}
+	if call, _ := server.LastCall(); call.Action != golatch.API_INSTANCE_ACTION || call.Path != "/api/1.0/instance/MyAccountID/i/"+instanceId {
+		t.Errorf("DeleteInstance() failed: unexpected call %v", call)
+	}
+}
+
+func TestTotps(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	latch := server.Latch("MyAppID")
+
+	if _, err := latch.CreateTotp("MyUserID", "me@example.com"); !errors.Is(err, golatch.ErrActionNotSupported) {
+		t.Errorf("CreateTotp() failed: expected ErrActionNotSupported for version %s, got %v", golatch.API_VERSION_1_0, err)
+	}
+
+	latch.SetAPIVersion(golatch.API_VERSION_3_0)
+	created, err := latch.CreateTotp("MyUserID", "me@example.com")
+	if err != nil {
+		t.Fatalf("CreateTotp() failed: unexpected error %v", err)
+	}
+	totpId := created.TotpId()
+	shown, err := latch.GetTotp(totpId)
+	if totp := shown.Totp(); err != nil || totp.Secret != created.Totp().Secret || totp.Issuer != "My Application" || !strings.HasPrefix(totp.ProvisioningURI(), "otpauth://totp/My%20Application:me@example.com?") {
+		t.Errorf("GetTotp() failed: unexpected TOTP %+v (error %v)", totp, err)
+	}
+
+	now := time.Now()
+	if err := latch.ValidateTotp(totpId, server.TotpCode("MyAppID", totpId, now)); err != nil {
+		t.Errorf("ValidateTotp() failed: expected current code to be valid, got %v", err)
+	}
+	if err := latch.ValidateTotp(totpId, server.TotpCode("MyAppID", totpId, now.Add(-2*time.Minute))); !errors.Is(err, golatch.ErrTotpCodeExpired) {
+		t.Errorf("ValidateTotp() failed: expected ErrTotpCodeExpired for an old code, got %v", err)
+	}
+	if err := latch.ValidateTotp(totpId, "12345"); !errors.Is(err, golatch.ErrInvalidTotpCode) {
+		t.Errorf("ValidateTotp() failed: expected ErrInvalidTotpCode, got %v", err)
+	}
+
+	if err := latch.DeleteTotp(totpId); err != nil {
+		t.Errorf("DeleteTotp() failed: unexpected error %v", err)
+	}
+	if _, err := latch.GetTotp(totpId); !errors.Is(err, golatch.ErrTotpNotFound) {
+		t.Errorf("GetTotp() failed: expected ErrTotpNotFound after DeleteTotp(), got %v", err)
+	}
+}
+
+func TestTotpCode(t *testing.T) {
+	//Test vector of RFC 6238 (SHA1, 59 seconds after the epoch), truncated to 6 digits
+	if code := totpCode("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", 59/TOTP_PERIOD); code != "287082" {
+		t.Errorf("totpCode() failed: expected %q, got %q", "287082", code)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	server.PairAccount("MyAppID", "MyAccountID")
+	server.AddHistoryEntry("MyAppID", "MyAccountID", golatch.LatchHistoryEntry{Time: 1000, Action: "USER_UPDATE", What: "status", Value: "off", Was: "on"})
+	latch := server.Latch("MyAppID")
+
+	latch.Status("MyAccountID", false, false)
+
+	response, err := latch.History("MyAccountID", time.Time{}, time.Time{})
+	if err != nil || response.HistoryCount() != 2 || response.Application().Name != "My Application" {
+		t.Fatalf("History() failed: expected 2 entries, got %v (error %v)", response, err)
+	}
+	if entry := response.History()[1]; entry.Action != "get" || entry.Value != golatch.LATCH_STATUS_ON {
+		t.Errorf("History() failed: expected status check entry, got %v", entry)
+	}
+
+	if response, _ := latch.History("MyAccountID", time.Unix(0, 0), time.Unix(2, 0)); response.HistoryCount() != 1 {
+		t.Errorf("History() failed: expected 1 entry between the dates, got %d", response.HistoryCount())
+	}
+}
+
+func TestUserAPI(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	user := server.AddUser("MyUserID", "MyUserSecret")
+	user.ApplicationsLimit = 1
+	latch := server.LatchUser("MyUserID")
+
+	added, err := latch.AddApplication(&golatch.LatchApplicationInfo{Name: "My App", ContactEmail: "me@example.com"})
+	if err != nil || added.AppID() == "" || added.Secret() == "" {
+		t.Fatalf("AddApplication() failed: unexpected response %v (error %v)", added, err)
+	}
+	if _, err := latch.AddApplication(&golatch.LatchApplicationInfo{Name: "Another App"}); !errors.Is(err, golatch.ErrSubscriptionRequired) {
+		t.Errorf("AddApplication() failed: expected subscription limit error, got %v", err)
+	}
+
+	latch.UpdateApplication(added.AppID(), &golatch.LatchApplicationInfo{Name: "Renamed"})
+	applications, err := latch.ShowApplications()
+	if application := applications.Applications()[added.AppID()]; err != nil || application.Name != "Renamed" || application.ContactEmail != "me@example.com" {
+		t.Errorf("ShowApplications() failed: unexpected application %v (error %v)", application, err)
+	}
+
+	//The new application can use the application API
+	server.PairAccount(added.AppID(), "MyAccountID")
+	if _, err := server.Latch(added.AppID()).Status("MyAccountID", false, false); err != nil {
+		t.Errorf("Status() failed: unexpected error using the new application %v", err)
+	}
+
+	subscription, err := latch.Subscription()
+	if err != nil || subscription.Applications().InUse != 1 || subscription.Applications().Limit != 1 || subscription.Users().InUse != 1 {
+		t.Errorf("Subscription() failed: unexpected subscription %v (error %v)", subscription, err)
+	}
+
+	if err := latch.DeleteApplication(added.AppID()); err != nil || server.Application(added.AppID()) != nil {
+		t.Errorf("DeleteApplication() failed: expected the application to be deleted (error %v)", err)
+	}
+}
+
+func TestSignatureVerification(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	server.PairAccount("MyAppID", "MyAccountID")
+
+	latch := golatch.NewLatch("MyAppID", "WrongSecret")
+	latch.SetAPIURL(server.URL)
+	if _, err := latch.Status("MyAccountID", false, false); !errors.Is(err, golatch.ErrInvalidSignature) {
+		t.Errorf("Status() failed: expected ErrInvalidSignature, got %v", err)
+	}
+
+	//Application credentials can't be used in the user API
+	user := golatch.NewLatchUser("MyAppID", "MySecretKey")
+	user.SetAPIURL(server.URL)
+	if _, err := user.Subscription(); !errors.Is(err, golatch.ErrInvalidSignature) {
+		t.Errorf("Subscription() failed: expected ErrInvalidSignature, got %v", err)
+	}
+
+	calls := server.CallsTo(golatch.API_CHECK_STATUS_ACTION)
+	if len(calls) != 1 || calls[0].Verified || calls[0].ID != "MyAppID" {
+		t.Errorf("CallsTo() failed: expected 1 unverified status call, got %v", calls)
+	}
+	if call, ok := server.LastCall(); !ok || call.Action != golatch.API_SUBSCRIPTION_ACTION {
+		t.Errorf("LastCall() failed: expected subscription call, got %v", call)
+	}
+
+	server.ResetCalls()
+	if len(server.Calls()) != 0 {
+		t.Errorf("ResetCalls() failed: expected no calls")
+	}
+}
+
+func TestAPIVersions(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	server.PairAccount("MyAppID", "MyAccountID")
+
+	latch := server.Latch("MyAppID")
+	latch.SetAPIVersion(golatch.API_VERSION_3_0)
+	if status, err := latch.Status("MyAccountID", false, false); err != nil || status.Status() != golatch.LATCH_STATUS_ON {
+		t.Errorf("Status() failed: expected status on with version %s, got %v (error %v)", golatch.API_VERSION_3_0, status, err)
+	}
+	if call, ok := server.LastCall(); !ok || call.Version != golatch.API_VERSION_3_0 || call.Action != golatch.API_CHECK_STATUS_ACTION {
+		t.Errorf("LastCall() failed: expected status call with version %s, got %v", golatch.API_VERSION_3_0, call)
+	}
+
+	latch.SetAPIVersion("0.1")
+	var http_error *golatch.LatchHttpError
+	if _, err := latch.Status("MyAccountID", false, false); !errors.As(err, &http_error) || http_error.StatusCode != 404 {
+		t.Errorf("Status() failed: expected 404 for an unknown version, got %v", err)
+	}
+}
@@ -0,0 +1,344 @@
+package golatchtest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/millenc/golatch"
+)
+
+//Application registered in the fake server
+type Application struct {
+	ID            string
+	Secret        string
+	Owner         string
+	Name          string
+	Description   string
+	ImageURL      string
+	ContactPhone  string
+	ContactEmail  string
+	TwoFactor     string
+	LockOnRequest string
+	//All the operations of the application, indexed by operation ID
+	Operations map[string]*Operation
+	//Paired accounts, indexed by account ID
+	Accounts map[string]*Account
+	//TOTPs of the users of the application, indexed by TOTP ID
+	Totps map[string]*Totp
+}
+
+//TOTP of a user of an application (SHA1, 6 digits and a period of 30 seconds)
+type Totp struct {
+	ID         string
+	UserID     string
+	CommonName string
+	//Base32 encoded secret
+	Secret    string
+	CreatedAt int64
+}
+
+//Operation of an application. ParentID is the ID of the application or of another operation
+type Operation struct {
+	ID            string
+	ParentID      string
+	Name          string
+	TwoFactor     string
+	LockOnRequest string
+}
+
+//Account paired with an application
+type Account struct {
+	ID       string
+	PairedOn int64
+	LastSeen int64
+	Status   string
+	//Status of the operations, indexed by operation ID (operations not included are on)
+	Operations map[string]string
+	History    []golatch.LatchHistoryEntry
+	//Instances of the account and of its operations, indexed by instance ID
+	Instances map[string]*Instance
+}
+
+//Instance of an account (or of one of its operations if OperationID is not empty) with its own latch
+type Instance struct {
+	ID          string
+	Name        string
+	OperationID string
+	Status      string
+}
+
+//User of the User API with its subscription
+type User struct {
+	ID           string
+	Secret       string
+	Subscription string
+	//Max number of applications, operations per application and paired accounts (-1 for no limit)
+	ApplicationsLimit int
+	OperationsLimit   int
+	UsersLimit        int
+}
+
+//Call received by the fake server
+type Call struct {
+	HttpMethod string
+	Path       string
+	//Action (status, pair, operation...) and version of the API
+	Action  string
+	Version string
+	//Application or user ID of the Authorization header
+	ID     string
+	Params url.Values
+	Header map[string][]string
+	//True if the request was properly signed
+	Verified bool
+}
+
+//Adds an application. owner is the ID of the user that owns it (it can be empty)
+func (s *Server) AddApplication(owner string, appID string, secret string, name string) *Application {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app := &Application{
+		ID:            appID,
+		Secret:        secret,
+		Owner:         owner,
+		Name:          name,
+		TwoFactor:     golatch.DISABLED,
+		LockOnRequest: golatch.DISABLED,
+		Operations:    make(map[string]*Operation),
+		Accounts:      make(map[string]*Account),
+		Totps:         make(map[string]*Totp),
+	}
+	s.apps[appID] = app
+	return app
+}
+
+//Adds a user of the User API (with no subscription limits)
+func (s *Server) AddUser(userID string, secret string) *User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := &User{ID: userID, Secret: secret, Subscription: "vip", ApplicationsLimit: -1, OperationsLimit: -1, UsersLimit: -1}
+	s.users[userID] = user
+	return user
+}
+
+//Adds an operation to an application. parentID is the ID of the application or of another operation
+func (s *Server) AddOperation(appID string, parentID string, operationID string, name string) *Operation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	operation := &Operation{ID: operationID, ParentID: parentID, Name: name, TwoFactor: golatch.DISABLED, LockOnRequest: golatch.DISABLED}
+	s.apps[appID].Operations[operationID] = operation
+	return operation
+}
+
+//Adds a token that can be used to pair an account with any application (only once)
+func (s *Server) AddPairingToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[token] = true
+}
+
+//Pairs an account with an application (the account's latch is on)
+func (s *Server) PairAccount(appID string, accountID string) *Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pair(s.apps[appID], accountID)
+}
+
+//Sets the status (golatch.LATCH_STATUS_ON or golatch.LATCH_STATUS_OFF) of an account
+func (s *Server) SetStatus(appID string, accountID string, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apps[appID].Accounts[accountID].Status = status
+}
+
+//Sets the status (golatch.LATCH_STATUS_ON or golatch.LATCH_STATUS_OFF) of an operation of an account
+func (s *Server) SetOperationStatus(appID string, accountID string, operationID string, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apps[appID].Accounts[accountID].Operations[operationID] = status
+}
+
+//Adds an instance to an account (or to one of its operations if operationID is not empty). The instance's latch is on
+func (s *Server) AddInstance(appID string, accountID string, operationID string, instanceID string, name string) *Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	instance := &Instance{ID: instanceID, Name: name, OperationID: operationID}
+	s.apps[appID].Accounts[accountID].Instances[instanceID] = instance
+	return instance
+}
+
+//Sets the status (golatch.LATCH_STATUS_ON or golatch.LATCH_STATUS_OFF) of an instance of an account
+func (s *Server) SetInstanceStatus(appID string, accountID string, instanceID string, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apps[appID].Accounts[accountID].Instances[instanceID].Status = status
+}
+
+//Adds an entry to the history of an account
+func (s *Server) AddHistoryEntry(appID string, accountID string, entry golatch.LatchHistoryEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := s.apps[appID].Accounts[accountID]
+	account.History = append(account.History, entry)
+}
+
+//Gets a copy of an application (nil if it doesn't exist)
+func (s *Server) Application(appID string) *Application {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app, ok := s.apps[appID]
+	if !ok {
+		return nil
+	}
+	copy := *app
+	return &copy
+}
+
+//Gets the status of an account ("" if it's not paired)
+func (s *Server) Status(appID string, accountID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if account, ok := s.apps[appID].Accounts[accountID]; ok {
+		return account.Status
+	}
+	return ""
+}
+
+//Gets the status of an operation of an account, not taking into account the status of its parents ("" if it's not paired)
+func (s *Server) OperationStatus(appID string, accountID string, operationID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if account, ok := s.apps[appID].Accounts[accountID]; ok {
+		return statusOrOn(account.Operations[operationID])
+	}
+	return ""
+}
+
+//Gets a copy of an instance of an account (nil if it doesn't exist). Its status doesn't take into account the status of its parents
+func (s *Server) Instance(appID string, accountID string, instanceID string) *Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.apps[appID].Accounts[accountID]
+	if !ok || account.Instances[instanceID] == nil {
+		return nil
+	}
+	copy := *account.Instances[instanceID]
+	copy.Status = statusOrOn(copy.Status)
+	return &copy
+}
+
+//Gets the code generated by a TOTP at the time provided ("" if the TOTP doesn't exist)
+func (s *Server) TotpCode(appID string, totpID string, t time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if totp, ok := s.apps[appID].Totps[totpID]; ok {
+		return totpCode(totp.Secret, t.Unix()/TOTP_PERIOD)
+	}
+	return ""
+}
+
+//Gets the calls received by the server
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Call(nil), s.calls...)
+}
+
+//Gets the calls received by the server for an action (golatch.API_CHECK_STATUS_ACTION, golatch.API_PAIR_ACTION...)
+func (s *Server) CallsTo(action string) (calls []Call) {
+	for _, call := range s.Calls() {
+		if call.Action == action {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+//Gets the last call received by the server (false if there are none)
+func (s *Server) LastCall() (Call, bool) {
+	calls := s.Calls()
+	if len(calls) == 0 {
+		return Call{}, false
+	}
+	return calls[len(calls)-1], true
+}
+
+//Forgets the calls received so far
+func (s *Server) ResetCalls() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = nil
+}
+
+//Pairs an account (must be called with the lock held)
+func (s *Server) pair(app *Application, accountID string) *Account {
+	account := &Account{ID: accountID, PairedOn: millis(s.now()), Status: golatch.LATCH_STATUS_ON, Operations: make(map[string]string), Instances: make(map[string]*Instance)}
+	app.Accounts[accountID] = account
+	return account
+}
+
+//Gets the operations whose parent is the ID provided (must be called with the lock held)
+func (app *Application) children(parentID string) (operations []*Operation) {
+	for _, operation := range app.Operations {
+		if operation.ParentID == parentID {
+			operations = append(operations, operation)
+		}
+	}
+	return operations
+}
+
+func statusOrOn(status string) string {
+	if status == "" {
+		return golatch.LATCH_STATUS_ON
+	}
+	return status
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+//Generates the code of a TOTP for a time step (RFC 6238)
+func totpCode(secret string, step int64) string {
+	key, _ := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000)
+}
+
+//Generates a random ID
+func randomID(length int) string {
+	b := make([]byte, (length+1)/2)
+	rand.Read(b)
+	return hex.EncodeToString(b)[:length]
+}
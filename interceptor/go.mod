@@ -0,0 +1,19 @@
+module github.com/millenc/golatch/interceptor
+
+go 1.25.0
+
+require (
+	github.com/millenc/golatch v0.0.0-20261018090159-19416b991569
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260414002931-afd174a4e478
+	google.golang.org/grpc v1.82.1
+)
+
+require (
+	golang.org/x/net v0.53.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+)
+
+//Use the root module of this repository when working on it (replace directives only apply to the main module)
+replace github.com/millenc/golatch => ../
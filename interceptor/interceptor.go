@@ -0,0 +1,130 @@
+//Package interceptor provides gRPC server interceptors that gate RPCs on the status of a latch
+package interceptor
+
+import (
+	"context"
+
+	"github.com/millenc/golatch"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//Domain used in the ErrorInfo details of the PermissionDenied statuses
+const ERROR_INFO_DOMAIN = "latch.elevenpaths.com"
+
+//Extracts the Latch account ID of the caller from the incoming metadata (empty if there's none)
+type AccountExtractor func(ctx context.Context, md metadata.MD) string
+
+type contextKey int
+
+const decisionKey contextKey = 0
+
+//Checks the status of the latch of the caller's account (or of the operation mapped to the RPC) before calling the handler
+type Interceptor struct {
+	Guard     *golatch.Guard
+	AccountID AccountExtractor
+	//Maps full method names (like "/bank.Transfers/Create") to operation IDs. An empty operation ID means the status of the application
+	//RPCs not included are not checked. If nil the status of the application is checked for all the RPCs
+	Operations map[string]string
+	//Let RPCs through when the verdict is golatch.VerdictUnknown (for example when the account is not paired)
+	AllowUnknown bool
+}
+
+//Returns a new interceptor that uses the guard provided to check the account returned by accountID
+func New(guard *golatch.Guard, accountID AccountExtractor, operations map[string]string) *Interceptor {
+	return &Interceptor{Guard: guard, AccountID: accountID, Operations: operations}
+}
+
+//Returns the unary server interceptor
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := i.check(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+//Returns the stream server interceptor
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.check(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+//Checks the latch for an RPC. Returns the context with the decision or a PermissionDenied error
+func (i *Interceptor) check(ctx context.Context, fullMethod string) (context.Context, error) {
+	operationId, ok := "", true
+	if i.Operations != nil {
+		operationId, ok = i.Operations[fullMethod]
+	}
+	if !ok {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	accountId := i.AccountID(ctx, md)
+	if accountId == "" {
+		return ctx, nil
+	}
+
+	var decision golatch.Decision
+	if operationId == "" {
+		decision = i.Guard.Check(ctx, accountId)
+	} else {
+		decision = i.Guard.CheckOperation(ctx, accountId, operationId)
+	}
+
+	if decision.Allowed() || (decision.Verdict == golatch.VerdictUnknown && i.AllowUnknown) {
+		return NewContext(ctx, decision), nil
+	}
+
+	return ctx, DeniedError(decision, operationId)
+}
+
+//Builds the PermissionDenied error returned when the access is denied
+//The decision is included in the status details as an ErrorInfo (reason, verdict and operation ID)
+func DeniedError(decision golatch.Decision, operationId string) error {
+	st := status.New(codes.PermissionDenied, "access denied by Latch: "+decision.Reason)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: decision.Reason,
+		Domain: ERROR_INFO_DOMAIN,
+		Metadata: map[string]string{
+			"verdict":     decision.Verdict.String(),
+			"operationId": operationId,
+		},
+	}); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+//Returns a copy of the context with the decision provided
+func NewContext(ctx context.Context, decision golatch.Decision) context.Context {
+	return context.WithValue(ctx, decisionKey, decision)
+}
+
+//Gets the decision stored in a context by the interceptors (the status response is available in decision.Response)
+func FromContext(ctx context.Context) (decision golatch.Decision, ok bool) {
+	decision, ok = ctx.Value(decisionKey).(golatch.Decision)
+	return decision, ok
+}
+
+//Server stream that carries the context with the decision
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
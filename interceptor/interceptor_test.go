@@ -0,0 +1,101 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/millenc/golatch"
+	"github.com/millenc/golatch/golatchtest"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//Fake Latch API with MyAccountID paired: the status of MyOperationID is off, everything else is on
+func newTestServer() *golatchtest.Server {
+	server := golatchtest.NewServer()
+	server.AddApplication("", "MyAppID", "MySecretKey", "My Application")
+	server.AddOperation("MyAppID", "MyAppID", "MyOperationID", "My Operation")
+	server.PairAccount("MyAppID", "MyAccountID")
+	server.SetOperationStatus("MyAppID", "MyAccountID", "MyOperationID", golatch.LATCH_STATUS_OFF)
+	return server
+}
+
+func newTestInterceptor(server *golatchtest.Server) *Interceptor {
+	return New(golatch.NewGuard(server.Latch("MyAppID"), golatch.FailClosed), func(ctx context.Context, md metadata.MD) string {
+		if values := md.Get("x-account"); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}, map[string]string{
+		"/bank.Transfers/Create": "MyOperationID",
+		"/bank.Accounts/Get":     "",
+	})
+}
+
+func incomingContext(account string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-account", account))
+}
+
+func TestUnary(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	unary := newTestInterceptor(server).Unary()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		decision, _ := FromContext(ctx)
+		return decision.Reason, nil
+	}
+
+	response, err := unary(incomingContext("MyAccountID"), nil, &grpc.UnaryServerInfo{FullMethod: "/bank.Accounts/Get"}, handler)
+	if err != nil || response != golatch.REASON_STATUS_ON {
+		t.Errorf("Unary() failed: expected handler to be called with the decision, got %v (error %v)", response, err)
+	}
+
+	_, err = unary(incomingContext("MyAccountID"), nil, &grpc.UnaryServerInfo{FullMethod: "/bank.Transfers/Create"}, handler)
+	st := status.Convert(err)
+	if st.Code() != codes.PermissionDenied || len(st.Details()) != 1 {
+		t.Fatalf("Unary() failed: expected PermissionDenied with details, got %v", err)
+	}
+	if info, ok := st.Details()[0].(*errdetails.ErrorInfo); !ok || info.Reason != golatch.REASON_STATUS_OFF || info.Metadata["operationId"] != "MyOperationID" {
+		t.Errorf("Unary() failed: unexpected status details %v", st.Details())
+	}
+
+	response, err = unary(incomingContext("MyAccountID"), nil, &grpc.UnaryServerInfo{FullMethod: "/bank.Other/Method"}, handler)
+	if err != nil || response != "" {
+		t.Errorf("Unary() failed: expected unmapped method not to be checked, got %v (error %v)", response, err)
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStream(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	stream := newTestInterceptor(server).Stream()
+	called := false
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		_, called = FromContext(stream.Context())
+		return nil
+	}
+
+	if err := stream(nil, &testServerStream{ctx: incomingContext("MyAccountID")}, &grpc.StreamServerInfo{FullMethod: "/bank.Accounts/Get"}, handler); err != nil || !called {
+		t.Errorf("Stream() failed: expected handler to be called with the decision (error %v)", err)
+	}
+
+	called = false
+	err := stream(nil, &testServerStream{ctx: incomingContext("MyAccountID")}, &grpc.StreamServerInfo{FullMethod: "/bank.Transfers/Create"}, handler)
+	if status.Code(err) != codes.PermissionDenied || called {
+		t.Errorf("Stream() failed: expected PermissionDenied without calling the handler, got %v", err)
+	}
+}
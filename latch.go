@@ -1,14 +1,18 @@
 package golatch
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	t "time"
 )
 
 type Latch struct {
-	AppID     string
-	SecretKey string
+	AppID       string
+	SecretKey   string
+	StatusCache *StatusCache
+	//Default options of the status requests (see SetDefaultStatusOptions())
+	DefaultStatusOptions []StatusOption
 	LatchAPI
 }
 
@@ -20,10 +24,41 @@ func NewLatch(appID string, secretKey string) *Latch {
 	}
 }
 
+//Constructs a new Latch struct that signs the requests with the signer provided instead of a secret key
+func NewLatchWithSigner(appID string, signer Signer) *Latch {
+	latch := &Latch{AppID: appID}
+	latch.SetSigner(signer)
+	return latch
+}
+
+//Performs a request against the API signed with the application's credentials
+func (l *Latch) doRequest(ctx context.Context, httpMethod string, query string, params url.Values, responseType LatchResponse) (*LatchResponse, error) {
+	return l.doSignedRequest(ctx, l.AppID, l.SecretKey, httpMethod, query, params, responseType)
+}
+
 //Pairs an account with the provided pairing token
 func (l *Latch) Pair(token string) (response *LatchPairResponse, err error) {
+	return l.PairWithContext(context.Background(), token)
+}
+
+//Same as Pair() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) PairWithContext(ctx context.Context, token string) (response *LatchPairResponse, err error) {
+	var resp *LatchResponse
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_GET, fmt.Sprint(API_PAIR_ACTION, "/", token), nil, &LatchPairResponse{}); err == nil {
+		response = (*resp).(*LatchPairResponse)
+	}
+	return response, err
+}
+
+//Pairs an account using it's account ID (only available in test and development environments)
+func (l *Latch) PairWithId(accountId string) (response *LatchPairResponse, err error) {
+	return l.PairWithIdWithContext(context.Background(), accountId)
+}
+
+//Same as PairWithId() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) PairWithIdWithContext(ctx context.Context, accountId string) (response *LatchPairResponse, err error) {
 	var resp *LatchResponse
-	if resp, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_GET, GetLatchURL(fmt.Sprint(API_PAIR_ACTION, "/", token)), nil, nil, t.Now()), &LatchPairResponse{}); err == nil {
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_GET, fmt.Sprint(API_PAIR_WITH_ID_ACTION, "/", accountId), nil, &LatchPairResponse{}); err == nil {
 		response = (*resp).(*LatchPairResponse)
 	}
 	return response, err
@@ -31,36 +66,71 @@ func (l *Latch) Pair(token string) (response *LatchPairResponse, err error) {
 
 //Unpairs an account, given it's account ID
 func (l *Latch) Unpair(accountId string) (err error) {
-	_, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_GET, GetLatchURL(fmt.Sprint(API_UNPAIR_ACTION, "/", accountId)), nil, nil, t.Now()), nil)
+	return l.UnpairWithContext(context.Background(), accountId)
+}
+
+//Same as Unpair() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) UnpairWithContext(ctx context.Context, accountId string) (err error) {
+	_, err = l.doRequest(ctx, HTTP_METHOD_GET, fmt.Sprint(API_UNPAIR_ACTION, "/", accountId), nil, nil)
+	l.invalidateStatus(accountId, err)
 	return err
 }
 
 //Locks an account, given it's account ID
 func (l *Latch) Lock(accountId string) (err error) {
-	_, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_GET, GetLatchURL(fmt.Sprint(API_LOCK_ACTION, "/", accountId)), nil, nil, t.Now()), nil)
+	return l.LockWithContext(context.Background(), accountId)
+}
+
+//Same as Lock() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) LockWithContext(ctx context.Context, accountId string) (err error) {
+	_, err = l.doRequest(ctx, HTTP_METHOD_GET, fmt.Sprint(API_LOCK_ACTION, "/", accountId), nil, nil)
+	l.invalidateStatus(accountId, err)
 	return err
 }
 
 //Unlocks an account, given it's account ID
 func (l *Latch) Unlock(accountId string) (err error) {
-	_, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_GET, GetLatchURL(fmt.Sprint(API_UNLOCK_ACTION, "/", accountId)), nil, nil, t.Now()), nil)
+	return l.UnlockWithContext(context.Background(), accountId)
+}
+
+//Same as Unlock() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) UnlockWithContext(ctx context.Context, accountId string) (err error) {
+	_, err = l.doRequest(ctx, HTTP_METHOD_GET, fmt.Sprint(API_UNLOCK_ACTION, "/", accountId), nil, nil)
+	l.invalidateStatus(accountId, err)
 	return err
 }
 
 //Locks an operation, given it's account ID and oeration ID
 func (l *Latch) LockOperation(accountId string, operationId string) (err error) {
-	_, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_GET, GetLatchURL(fmt.Sprint(API_LOCK_ACTION, "/", accountId, "/op/", operationId)), nil, nil, t.Now()), nil)
+	return l.LockOperationWithContext(context.Background(), accountId, operationId)
+}
+
+//Same as LockOperation() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) LockOperationWithContext(ctx context.Context, accountId string, operationId string) (err error) {
+	_, err = l.doRequest(ctx, HTTP_METHOD_GET, fmt.Sprint(API_LOCK_ACTION, "/", accountId, "/op/", operationId), nil, nil)
+	l.invalidateStatus(accountId, err)
 	return err
 }
 
 //Unlocks an operation, given it's account ID and oeration ID
 func (l *Latch) UnlockOperation(accountId string, operationId string) (err error) {
-	_, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_GET, GetLatchURL(fmt.Sprint(API_UNLOCK_ACTION, "/", accountId, "/op/", operationId)), nil, nil, t.Now()), nil)
+	return l.UnlockOperationWithContext(context.Background(), accountId, operationId)
+}
+
+//Same as UnlockOperation() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) UnlockOperationWithContext(ctx context.Context, accountId string, operationId string) (err error) {
+	_, err = l.doRequest(ctx, HTTP_METHOD_GET, fmt.Sprint(API_UNLOCK_ACTION, "/", accountId, "/op/", operationId), nil, nil)
+	l.invalidateStatus(accountId, err)
 	return err
 }
 
 //Adds a new operation
 func (l *Latch) AddOperation(parentId string, name string, twoFactor string, lockOnRequest string) (response *LatchAddOperationResponse, err error) {
+	return l.AddOperationWithContext(context.Background(), parentId, name, twoFactor, lockOnRequest)
+}
+
+//Same as AddOperation() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) AddOperationWithContext(ctx context.Context, parentId string, name string, twoFactor string, lockOnRequest string) (response *LatchAddOperationResponse, err error) {
 	var resp *LatchResponse
 
 	params := url.Values{}
@@ -69,7 +139,7 @@ func (l *Latch) AddOperation(parentId string, name string, twoFactor string, loc
 	params.Set("two_factor", twoFactor)
 	params.Set("lock_on_request", lockOnRequest)
 
-	if resp, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_PUT, GetLatchURL(API_OPERATION_ACTION), nil, params, t.Now()), &LatchAddOperationResponse{}); err == nil {
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_PUT, API_OPERATION_ACTION, params, &LatchAddOperationResponse{}); err == nil {
 		response = (*resp).(*LatchAddOperationResponse)
 	}
 	return response, err
@@ -77,6 +147,11 @@ func (l *Latch) AddOperation(parentId string, name string, twoFactor string, loc
 
 //Updates an existing operation
 func (l *Latch) UpdateOperation(operationId string, name string, twoFactor string, lockOnRequest string) (err error) {
+	return l.UpdateOperationWithContext(context.Background(), operationId, name, twoFactor, lockOnRequest)
+}
+
+//Same as UpdateOperation() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) UpdateOperationWithContext(ctx context.Context, operationId string, name string, twoFactor string, lockOnRequest string) (err error) {
 	params := url.Values{}
 	params.Set("name", name)
 	if twoFactor != NOT_SET {
@@ -86,19 +161,29 @@ func (l *Latch) UpdateOperation(operationId string, name string, twoFactor strin
 		params.Set("lock_on_request", lockOnRequest)
 	}
 
-	_, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_POST, GetLatchURL(fmt.Sprint(API_OPERATION_ACTION, "/", operationId)), nil, params, t.Now()), nil)
+	_, err = l.doRequest(ctx, HTTP_METHOD_POST, fmt.Sprint(API_OPERATION_ACTION, "/", operationId), params, nil)
 	return err
 }
 
 //Deletes an existing operation
 func (l *Latch) DeleteOperation(operationId string) (err error) {
-	_, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_DELETE, GetLatchURL(fmt.Sprint(API_OPERATION_ACTION, "/", operationId)), nil, nil, t.Now()), nil)
+	return l.DeleteOperationWithContext(context.Background(), operationId)
+}
+
+//Same as DeleteOperation() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) DeleteOperationWithContext(ctx context.Context, operationId string) (err error) {
+	_, err = l.doRequest(ctx, HTTP_METHOD_DELETE, fmt.Sprint(API_OPERATION_ACTION, "/", operationId), nil, nil)
 	return err
 }
 
 //Shows operations information
 //If operationId is empty this function will retrieve all the operations of the app
 func (l *Latch) ShowOperation(operationId string) (response *LatchShowOperationResponse, err error) {
+	return l.ShowOperationWithContext(context.Background(), operationId)
+}
+
+//Same as ShowOperation() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) ShowOperationWithContext(ctx context.Context, operationId string) (response *LatchShowOperationResponse, err error) {
 	var resp *LatchResponse
 	var operation string
 
@@ -106,7 +191,7 @@ func (l *Latch) ShowOperation(operationId string) (response *LatchShowOperationR
 		operation += "/" + operationId
 	}
 
-	if resp, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_GET, GetLatchURL(fmt.Sprint(API_OPERATION_ACTION, operation)), nil, nil, t.Now()), &LatchShowOperationResponse{}); err == nil {
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_GET, fmt.Sprint(API_OPERATION_ACTION, operation), nil, &LatchShowOperationResponse{}); err == nil {
 		response = (*resp).(*LatchShowOperationResponse)
 	}
 	return response, err
@@ -115,38 +200,57 @@ func (l *Latch) ShowOperation(operationId string) (response *LatchShowOperationR
 //Gets the status of an account, given it's account ID
 //If nootp is true, the one time password won't be included in the response
 //If silent is true Latch will not send push notifications to the client (requires SILVER, GOLD or PLATINUM subscription)
+//The rest of the default status options of the client are applied too (see SetDefaultStatusOptions())
 func (l *Latch) Status(accountId string, nootp bool, silent bool) (response *LatchStatusResponse, err error) {
-	query := fmt.Sprint(API_CHECK_STATUS_ACTION, "/", accountId)
-	if nootp {
-		query = fmt.Sprint(query, "/", API_NOOTP_SUFFIX)
-	}
-	if silent {
-		query = fmt.Sprint(query, "/", API_SILENT_SUFFIX)
-	}
+	return l.StatusWithContext(context.Background(), accountId, nootp, silent)
+}
 
-	return l.StatusRequest(query)
+//Same as Status() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) StatusWithContext(ctx context.Context, accountId string, nootp bool, silent bool) (response *LatchStatusResponse, err error) {
+	return l.status(ctx, accountId, l.statusOptions(WithOperation(""), WithInstance(""), WithNoOtp(nootp), WithSilent(silent)))
 }
 
 //Gets the status of an operation, given it's account ID and operation ID
 //If nootp is true, the one time password won't be included in the response
 //If silent is true Latch will not send push notifications to the client (requires SILVER, GOLD or PLATINUM subscription)
+//The rest of the default status options of the client are applied too (see SetDefaultStatusOptions())
 func (l *Latch) OperationStatus(accountId string, operationId string, nootp bool, silent bool) (response *LatchStatusResponse, err error) {
-	query := fmt.Sprint(API_CHECK_STATUS_ACTION, "/", accountId, "/op/", operationId)
-	if nootp {
-		query = fmt.Sprint(query, "/", API_NOOTP_SUFFIX)
-	}
-	if silent {
-		query = fmt.Sprint(query, "/", API_SILENT_SUFFIX)
+	return l.OperationStatusWithContext(context.Background(), accountId, operationId, nootp, silent)
+}
+
+//Same as OperationStatus() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) OperationStatusWithContext(ctx context.Context, accountId string, operationId string, nootp bool, silent bool) (response *LatchStatusResponse, err error) {
+	return l.status(ctx, accountId, l.statusOptions(WithOperation(operationId), WithInstance(""), WithNoOtp(nootp), WithSilent(silent)))
+}
+
+//Performs a status request through the status cache (if one has been set)
+func (l *Latch) cachedStatusRequest(ctx context.Context, key StatusCacheKey, query string) (*LatchStatusResponse, error) {
+	if l.StatusCache == nil {
+		return l.StatusRequestWithContext(ctx, query)
 	}
 
-	return l.StatusRequest(query)
+	return l.StatusCache.get(ctx, key, func(ctx context.Context) (*LatchStatusResponse, error) {
+		return l.StatusRequestWithContext(ctx, query)
+	})
+}
+
+//Removes the cached statuses of an account after a successful change of its latches
+func (l *Latch) invalidateStatus(accountId string, err error) {
+	if err == nil && l.StatusCache != nil {
+		l.StatusCache.Invalidate(accountId)
+	}
 }
 
 //Performs a status request (application or operation) against the query URL provided
 //Returns a LatchStatusResponse struct on success
 func (l *Latch) StatusRequest(query string) (response *LatchStatusResponse, err error) {
+	return l.StatusRequestWithContext(context.Background(), query)
+}
+
+//Same as StatusRequest() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) StatusRequestWithContext(ctx context.Context, query string) (response *LatchStatusResponse, err error) {
 	var resp *LatchResponse
-	if resp, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_GET, GetLatchURL(query), nil, nil, t.Now()), &LatchStatusResponse{}); err == nil {
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_GET, query, nil, &LatchStatusResponse{}); err == nil {
 		response = (*resp).(*LatchStatusResponse)
 	}
 	return response, err
@@ -154,6 +258,11 @@ func (l *Latch) StatusRequest(query string) (response *LatchStatusResponse, err
 
 //Gets the account's history between the from and to dates
 func (l *Latch) History(accountId string, from t.Time, to t.Time) (response *LatchHistoryResponse, err error) {
+	return l.HistoryWithContext(context.Background(), accountId, from, to)
+}
+
+//Same as History() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) HistoryWithContext(ctx context.Context, accountId string, from t.Time, to t.Time) (response *LatchHistoryResponse, err error) {
 	var resp *LatchResponse
 
 	query := fmt.Sprintf("%s/%s", API_HISTORY_ACTION, accountId)
@@ -168,8 +277,177 @@ func (l *Latch) History(accountId string, from t.Time, to t.Time) (response *Lat
 		query = fmt.Sprint(query, fmt.Sprintf("/%d", to.UnixNano()/1000000))
 	}
 
-	if resp, err = l.DoRequest(NewLatchRequest(l.AppID, l.SecretKey, HTTP_METHOD_GET, GetLatchURL(query), nil, nil, t.Now()), &LatchHistoryResponse{AppID: l.AppID}); err == nil {
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_GET, query, nil, &LatchHistoryResponse{AppID: l.AppID}); err == nil {
 		response = (*resp).(*LatchHistoryResponse)
 	}
 	return response, err
 }
+
+//Adds instances to an account (or to one of its operations if operationId is not empty), given their names
+func (l *Latch) AddInstance(accountId string, operationId string, names ...string) (response *LatchAddInstanceResponse, err error) {
+	return l.AddInstanceWithContext(context.Background(), accountId, operationId, names...)
+}
+
+//Same as AddInstance() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) AddInstanceWithContext(ctx context.Context, accountId string, operationId string, names ...string) (response *LatchAddInstanceResponse, err error) {
+	var resp *LatchResponse
+
+	params := url.Values{}
+	for _, name := range names {
+		params.Add("instances", name)
+	}
+
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_PUT, instanceQuery(API_INSTANCE_ACTION, accountId, operationId, ""), params, &LatchAddInstanceResponse{}); err == nil {
+		response = (*resp).(*LatchAddInstanceResponse)
+	}
+	return response, err
+}
+
+//Renames an instance of an account (or of one of its operations if operationId is not empty)
+func (l *Latch) UpdateInstance(accountId string, operationId string, instanceId string, name string) (err error) {
+	return l.UpdateInstanceWithContext(context.Background(), accountId, operationId, instanceId, name)
+}
+
+//Same as UpdateInstance() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) UpdateInstanceWithContext(ctx context.Context, accountId string, operationId string, instanceId string, name string) (err error) {
+	params := url.Values{}
+	params.Set("name", name)
+
+	_, err = l.doRequest(ctx, HTTP_METHOD_POST, instanceQuery(API_INSTANCE_ACTION, accountId, operationId, instanceId), params, nil)
+	return err
+}
+
+//Deletes an instance of an account (or of one of its operations if operationId is not empty)
+func (l *Latch) DeleteInstance(accountId string, operationId string, instanceId string) (err error) {
+	return l.DeleteInstanceWithContext(context.Background(), accountId, operationId, instanceId)
+}
+
+//Same as DeleteInstance() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) DeleteInstanceWithContext(ctx context.Context, accountId string, operationId string, instanceId string) (err error) {
+	_, err = l.doRequest(ctx, HTTP_METHOD_DELETE, instanceQuery(API_INSTANCE_ACTION, accountId, operationId, instanceId), nil, nil)
+	l.invalidateStatus(accountId, err)
+	return err
+}
+
+//Shows the instances of an account (or of one of its operations if operationId is not empty)
+func (l *Latch) ShowInstances(accountId string, operationId string) (response *LatchShowInstancesResponse, err error) {
+	return l.ShowInstancesWithContext(context.Background(), accountId, operationId)
+}
+
+//Same as ShowInstances() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) ShowInstancesWithContext(ctx context.Context, accountId string, operationId string) (response *LatchShowInstancesResponse, err error) {
+	var resp *LatchResponse
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_GET, instanceQuery(API_INSTANCE_ACTION, accountId, operationId, ""), nil, &LatchShowInstancesResponse{}); err == nil {
+		response = (*resp).(*LatchShowInstancesResponse)
+	}
+	return response, err
+}
+
+//Gets the status of an instance of an account (or of one of its operations if operationId is not empty)
+//If nootp is true, the one time password won't be included in the response
+//If silent is true Latch will not send push notifications to the client (requires SILVER, GOLD or PLATINUM subscription)
+//The rest of the default status options of the client are applied too (see SetDefaultStatusOptions())
+func (l *Latch) InstanceStatus(accountId string, operationId string, instanceId string, nootp bool, silent bool) (response *LatchStatusResponse, err error) {
+	return l.InstanceStatusWithContext(context.Background(), accountId, operationId, instanceId, nootp, silent)
+}
+
+//Same as InstanceStatus() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) InstanceStatusWithContext(ctx context.Context, accountId string, operationId string, instanceId string, nootp bool, silent bool) (response *LatchStatusResponse, err error) {
+	return l.status(ctx, accountId, l.statusOptions(WithOperation(operationId), WithInstance(instanceId), WithNoOtp(nootp), WithSilent(silent)))
+}
+
+//Locks an instance of an account (or of one of its operations if operationId is not empty)
+func (l *Latch) LockInstance(accountId string, operationId string, instanceId string) (err error) {
+	return l.LockInstanceWithContext(context.Background(), accountId, operationId, instanceId)
+}
+
+//Same as LockInstance() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) LockInstanceWithContext(ctx context.Context, accountId string, operationId string, instanceId string) (err error) {
+	_, err = l.doRequest(ctx, HTTP_METHOD_GET, instanceQuery(API_LOCK_ACTION, accountId, operationId, instanceId), nil, nil)
+	l.invalidateStatus(accountId, err)
+	return err
+}
+
+//Unlocks an instance of an account (or of one of its operations if operationId is not empty)
+func (l *Latch) UnlockInstance(accountId string, operationId string, instanceId string) (err error) {
+	return l.UnlockInstanceWithContext(context.Background(), accountId, operationId, instanceId)
+}
+
+//Same as UnlockInstance() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) UnlockInstanceWithContext(ctx context.Context, accountId string, operationId string, instanceId string) (err error) {
+	_, err = l.doRequest(ctx, HTTP_METHOD_GET, instanceQuery(API_UNLOCK_ACTION, accountId, operationId, instanceId), nil, nil)
+	l.invalidateStatus(accountId, err)
+	return err
+}
+
+//Builds the query of the requests for instances: {action}/{accountId}[/op/{operationId}][/i/{instanceId}]
+func instanceQuery(action string, accountId string, operationId string, instanceId string) string {
+	query := fmt.Sprint(action, "/", accountId)
+	if operationId != "" {
+		query = fmt.Sprint(query, "/op/", operationId)
+	}
+	if instanceId != "" {
+		query = fmt.Sprint(query, "/i/", instanceId)
+	}
+	return query
+}
+
+//Creates a TOTP for a user of the application, given its user ID and the name shown in authenticator apps
+//TOTPs are only available in version 3.0 of the API (see SetAPIVersion())
+func (l *Latch) CreateTotp(userId string, commonName string) (response *LatchTotpResponse, err error) {
+	return l.CreateTotpWithContext(context.Background(), userId, commonName)
+}
+
+//Same as CreateTotp() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) CreateTotpWithContext(ctx context.Context, userId string, commonName string) (response *LatchTotpResponse, err error) {
+	var resp *LatchResponse
+
+	params := url.Values{}
+	params.Set("userId", userId)
+	params.Set("commonName", commonName)
+
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_POST, API_TOTP_ACTION, params, &LatchTotpResponse{}); err == nil {
+		response = (*resp).(*LatchTotpResponse)
+	}
+	return response, err
+}
+
+//Gets a TOTP with its provisioning data (secret, QR code and otpauth URI), given its TOTP ID
+func (l *Latch) GetTotp(totpId string) (response *LatchTotpResponse, err error) {
+	return l.GetTotpWithContext(context.Background(), totpId)
+}
+
+//Same as GetTotp() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) GetTotpWithContext(ctx context.Context, totpId string) (response *LatchTotpResponse, err error) {
+	var resp *LatchResponse
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_GET, fmt.Sprint(API_TOTP_ACTION, "/", totpId), nil, &LatchTotpResponse{}); err == nil {
+		response = (*resp).(*LatchTotpResponse)
+	}
+	return response, err
+}
+
+//Validates a code generated by a TOTP
+//Returns nil if the code is valid, ErrInvalidTotpCode or ErrTotpCodeExpired if it's not (use errors.Is() to check them)
+func (l *Latch) ValidateTotp(totpId string, code string) (err error) {
+	return l.ValidateTotpWithContext(context.Background(), totpId, code)
+}
+
+//Same as ValidateTotp() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) ValidateTotpWithContext(ctx context.Context, totpId string, code string) (err error) {
+	params := url.Values{}
+	params.Set("code", code)
+
+	_, err = l.doRequest(ctx, HTTP_METHOD_POST, fmt.Sprint(API_TOTP_ACTION, "/", totpId, "/validate"), params, nil)
+	return err
+}
+
+//Deletes a TOTP, given its TOTP ID
+func (l *Latch) DeleteTotp(totpId string) (err error) {
+	return l.DeleteTotpWithContext(context.Background(), totpId)
+}
+
+//Same as DeleteTotp() but using a context that can cancel the request or set a deadline for it
+func (l *Latch) DeleteTotpWithContext(ctx context.Context, totpId string) (err error) {
+	_, err = l.doRequest(ctx, HTTP_METHOD_DELETE, fmt.Sprint(API_TOTP_ACTION, "/", totpId), nil, nil)
+	return err
+}
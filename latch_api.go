@@ -1,44 +1,123 @@
 package golatch
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 )
 
+//HTTP client shared by all the LatchAPI structs that don't specify their own client, transport or proxy
+//Sharing the client allows connections to be pooled and reused between requests
+var DefaultHttpClient = &http.Client{}
+
+//Transports used for requests through a proxy, indexed by proxy URL (so they can be reused too)
+var proxyTransports = struct {
+	sync.Mutex
+	transports map[string]*http.Transport
+}{transports: make(map[string]*http.Transport)}
+
 type LatchAPI struct {
+	APIURL            string
+	APIPath           string
+	APIVersion        string
 	Proxy             *url.URL
+	HttpClient        *http.Client
+	Transport         http.RoundTripper
+	RetryPolicy       *RetryPolicy
+	RateLimit         *RateLimitPolicy
+	Clock             Clock
+	CorrectClockSkew  bool
+	Logger            *slog.Logger
+	Observer          RequestObserver
+	Signer            Signer
+	XHeaders          map[string]string
 	OnRequestStart    func(request *LatchRequest)
 	OnResponseReceive func(request *LatchRequest, response *http.Response, responseBody string)
+
+	clockSkew int64
 }
 
+//Performs a request against the Latch API and decodes the response into responseType (if not nil)
 func (l *LatchAPI) DoRequest(request *LatchRequest, responseType LatchResponse) (response *LatchResponse, err error) {
-	var client *http.Client
+	return l.DoRequestWithContext(context.Background(), request, responseType)
+}
+
+//Same as DoRequest() but using a context that can cancel the request or set a deadline for it
+//If the context is canceled or its deadline is exceeded the returned error will match ErrRequestCanceled or ErrRequestTimeout respectively (use errors.Is())
+//Failed requests are retried according to the retry policy (if one has been set)
+func (l *LatchAPI) DoRequestWithContext(ctx context.Context, request *LatchRequest, responseType LatchResponse) (response *LatchResponse, err error) {
+	if l.Observer != nil {
+		ctx = l.Observer.RequestStart(ctx, request)
+		defer func() {
+			l.Observer.RequestEnd(ctx, request, statusCode(err), err)
+		}()
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			//Sign every attempt with a fresh date
+			request.Date = l.Now()
+		}
+
+		if err = l.RateLimit.wait(ctx, request); err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		response, err = l.doRequestAttempt(ctx, request, attempt, responseType)
+		l.logAttempt(ctx, request, attempt, time.Since(start), err)
+
+		if !l.RetryPolicy.shouldRetry(ctx, request, attempt, err) {
+			return response, err
+		}
+		if waitErr := l.RetryPolicy.wait(ctx, attempt); waitErr != nil {
+			return nil, contextError(ctx, err)
+		}
+	}
+}
+
+//Performs a single attempt of a request
+func (l *LatchAPI) doRequestAttempt(ctx context.Context, request *LatchRequest, attempt int, responseType LatchResponse) (response *LatchResponse, err error) {
 	var resp *http.Response
 	var body []byte
 
-	//Initialize the client
-	client = &http.Client{}
-	if l.Proxy != nil {
-		client.Transport = &http.Transport{Proxy: http.ProxyURL(l.Proxy)}
-	}
+	client := l.GetHttpClient()
 
-	//Perform the request
-	req := request.GetHttpRequest()
+	//Sign and perform the request
+	req, err := request.GetHttpRequestWithContext(ctx)
+	if err != nil {
+		err = contextError(ctx, err)
+		return
+	}
 
+	if l.Observer != nil {
+		ctx = l.Observer.AttemptStart(ctx, request, attempt, req)
+		req = req.WithContext(ctx)
+		defer func() {
+			l.Observer.AttemptEnd(ctx, request, attempt, statusCode(err), err)
+		}()
+	}
 	if l.OnRequestStart != nil {
 		l.OnRequestStart(request)
 	}
 	if resp, err = client.Do(req); err != nil {
+		err = contextError(ctx, err)
 		return
 	}
+	l.measureClockSkew(resp)
 
 	//Get the response's body
 	defer resp.Body.Close()
 	if body, err = ioutil.ReadAll(resp.Body); err != nil {
+		err = contextError(ctx, err)
 		return
 	}
 
@@ -48,7 +127,7 @@ func (l *LatchAPI) DoRequest(request *LatchRequest, responseType LatchResponse)
 
 	//Handle HTTP errors
 	if resp.StatusCode != 200 {
-		err = errors.New(fmt.Sprintf("HTTP error [%d] body: %s", resp.StatusCode, body))
+		err = &LatchHttpError{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
 		return
 	}
 
@@ -63,7 +142,7 @@ func (l *LatchAPI) DoRequest(request *LatchRequest, responseType LatchResponse)
 
 	//Decode response into a typed response (if one has been specified)
 	if responseType != nil {
-		err = responseType.Unmarshal(string(body))
+		err = decodeResponse(request, body, responseType)
 		response = &responseType
 	}
 
@@ -75,7 +154,148 @@ func (l *LatchAPI) SetProxy(proxyURL *url.URL) {
 	l.Proxy = proxyURL
 }
 
-//Gets the complete url for a request
+//Sets the HTTP client to be used in all requests to the API
+//When set it takes precedence over the transport and proxy settings
+func (l *LatchAPI) SetHttpClient(client *http.Client) {
+	l.HttpClient = client
+}
+
+//Sets the transport (http.RoundTripper) to be used in all requests to the API
+//When set it takes precedence over the proxy setting
+func (l *LatchAPI) SetTransport(transport http.RoundTripper) {
+	l.Transport = transport
+}
+
+//Gets the HTTP client used to perform requests, in this order of preference:
+//the client set with SetHttpClient(), a client using the transport set with SetTransport(), a client using the proxy set with SetProxy() or DefaultHttpClient
+func (l *LatchAPI) GetHttpClient() *http.Client {
+	switch {
+	case l.HttpClient != nil:
+		return l.HttpClient
+	case l.Transport != nil:
+		return &http.Client{Transport: l.Transport}
+	case l.Proxy != nil:
+		return &http.Client{Transport: getProxyTransport(l.Proxy)}
+	}
+
+	return DefaultHttpClient
+}
+
+//Gets a transport that sends requests through the proxy provided
+//Transports are created once per proxy URL and reused afterwards
+func getProxyTransport(proxyURL *url.URL) *http.Transport {
+	proxyTransports.Lock()
+	defer proxyTransports.Unlock()
+
+	key := proxyURL.String()
+	if transport, ok := proxyTransports.transports[key]; ok {
+		return transport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	proxyTransports.transports[key] = transport
+
+	return transport
+}
+
+//Sets the base URL of the API (scheme and host, for example https://latch.elevenpaths.com)
+//Returns an error if the URL is not valid
+func (l *LatchAPI) SetAPIURL(apiURL string) error {
+	if _, err := parseAPIURL(apiURL); err != nil {
+		return err
+	}
+	l.APIURL = apiURL
+	return nil
+}
+
+//Sets the path of the API (for example /api)
+func (l *LatchAPI) SetAPIPath(apiPath string) {
+	l.APIPath = apiPath
+}
+
+//Sets the version of the API (for example API_VERSION_3_0)
+//Versions that are not registered (see RegisterAPIVersion()) are used as they are, without checking the actions available
+func (l *LatchAPI) SetAPIVersion(apiVersion string) {
+	l.APIVersion = apiVersion
+}
+
+//Gets the complete url for a request using the configured API URL, path and version
+//Empty settings default to the values of the API_URL, API_PATH and API_VERSION constants
+func (l *LatchAPI) GetLatchURL(queryString string) (*url.URL, error) {
+	apiURL, apiPath, apiVersion := l.APIURL, l.APIPath, l.APIVersion
+	if apiURL == "" {
+		apiURL = API_URL
+	}
+	if apiPath == "" {
+		apiPath = API_PATH
+	}
+	if apiVersion == "" {
+		apiVersion = API_VERSION
+	}
+
+	base, err := parseAPIURL(apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	path := strings.TrimRight(base.Path, "/")
+	if apiPath = strings.Trim(apiPath, "/"); apiPath != "" {
+		path = fmt.Sprint(path, "/", apiPath)
+	}
+
+	latch_url, err := base.Parse(fmt.Sprint(path, "/", apiVersion, "/", queryString))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Latch API request URL: %w", err)
+	}
+
+	return latch_url, nil
+}
+
+//Builds a request for the query provided signed with the credentials provided and performs it
+func (l *LatchAPI) doSignedRequest(ctx context.Context, id string, secretKey string, httpMethod string, query string, params url.Values, responseType LatchResponse) (*LatchResponse, error) {
+	versionQuery, err := l.versionQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	latch_url, err := l.GetLatchURL(versionQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateXHeaders(l.XHeaders); err != nil {
+		return nil, err
+	}
+
+	request := NewLatchRequest(id, secretKey, httpMethod, latch_url, l.XHeaders, params, l.Now())
+	request.Signer = l.Signer
+	request.Action = strings.SplitN(query, "/", 2)[0]
+	request.APIVersion = l.GetAPIVersion()
+
+	return l.DoRequestWithContext(ctx, request, responseType)
+}
+
+//Parses and validates a base API URL (it must be an absolute http or https URL without query or fragment)
+func parseAPIURL(apiURL string) (*url.URL, error) {
+	base, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Latch API URL %q: %w", apiURL, err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("invalid Latch API URL %q: scheme must be http or https", apiURL)
+	}
+	if base.Host == "" {
+		return nil, fmt.Errorf("invalid Latch API URL %q: missing host", apiURL)
+	}
+	if base.RawQuery != "" || base.Fragment != "" {
+		return nil, fmt.Errorf("invalid Latch API URL %q: query and fragment are not allowed", apiURL)
+	}
+
+	return base, nil
+}
+
+//Gets the complete url for a request against the default API endpoint
+//Use the GetLatchURL() method of LatchAPI to take into account the configured endpoint
 func GetLatchURL(queryString string) *url.URL {
 	latch_url, err := (&url.URL{}).Parse(fmt.Sprint(API_URL, API_PATH, "/", API_VERSION, "/", queryString))
 	if err != nil {
@@ -84,3 +304,49 @@ func GetLatchURL(queryString string) *url.URL {
 
 	return latch_url
 }
+
+//Observes the requests sent to the API (used to instrument the client, for example to trace requests)
+type RequestObserver interface {
+	//Called when a request starts, before its first attempt. The returned context is used for the attempts of the request
+	RequestStart(ctx context.Context, request *LatchRequest) context.Context
+	//Called before each attempt of a request (numbered from 1) once it has been signed. The returned context is used for the HTTP request
+	//(headers can be added to httpRequest)
+	AttemptStart(ctx context.Context, request *LatchRequest, attempt int, httpRequest *http.Request) context.Context
+	//Called after each attempt with the context returned by AttemptStart(), the HTTP status code (0 if there was no response) and the error (if any)
+	AttemptEnd(ctx context.Context, request *LatchRequest, attempt int, statusCode int, err error)
+	//Called when the request ends with the context returned by RequestStart(), the HTTP status code and the error of the request: the ones
+	//of the last attempt or the error that stopped it before (a signing error, the rate limit, a canceled context...)
+	RequestEnd(ctx context.Context, request *LatchRequest, statusCode int, err error)
+}
+
+//Sets the observer notified of every request sent to the API (nil disables it)
+func (l *LatchAPI) SetObserver(observer RequestObserver) {
+	l.Observer = observer
+}
+
+//Gets the HTTP status code of the response that caused an error (200 if there was no error or Latch answered with an error, 0 if there was no response)
+func statusCode(err error) int {
+	var latch_error *LatchError
+	var http_error *LatchHttpError
+
+	switch {
+	case err == nil, errors.As(err, &latch_error):
+		return http.StatusOK
+	case errors.As(err, &http_error):
+		return http_error.StatusCode
+	}
+	return 0
+}
+
+//Translates errors caused by the cancellation or expiration of the context into ErrRequestCanceled or ErrRequestTimeout
+//The original error is still wrapped so it can be inspected using errors.Is() or errors.As()
+func contextError(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.Canceled:
+		return fmt.Errorf("%w: %w", ErrRequestCanceled, err)
+	case context.DeadlineExceeded:
+		return fmt.Errorf("%w: %w", ErrRequestTimeout, err)
+	}
+
+	return err
+}
@@ -0,0 +1,246 @@
+package golatch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//Starts a test server that waits until the client gives up before answering
+func newSlowTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+}
+
+func newTestRequest(serverURL string) *LatchRequest {
+	request_url, _ := url.Parse(serverURL + "/api/1.0/status/MyAccountID")
+	return NewLatchRequest("MyAppID", "MySecretKey", HTTP_METHOD_GET, request_url, nil, nil, time.Now())
+}
+
+func TestDoRequestWithContextCanceled(t *testing.T) {
+	server := newSlowTestServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	api := &LatchAPI{}
+	_, err := api.DoRequestWithContext(ctx, newTestRequest(server.URL), &LatchStatusResponse{})
+	if !errors.Is(err, ErrRequestCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("DoRequestWithContext() failed: expected ErrRequestCanceled, got %v", err)
+	}
+	if errors.Is(err, ErrRequestTimeout) {
+		t.Errorf("DoRequestWithContext() failed: canceled request should not match ErrRequestTimeout, got %v", err)
+	}
+}
+
+func TestDoRequestWithContextTimeout(t *testing.T) {
+	server := newSlowTestServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	api := &LatchAPI{}
+	_, err := api.DoRequestWithContext(ctx, newTestRequest(server.URL), &LatchStatusResponse{})
+	if !errors.Is(err, ErrRequestTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DoRequestWithContext() failed: expected ErrRequestTimeout, got %v", err)
+	}
+	if errors.Is(err, ErrRequestCanceled) {
+		t.Errorf("DoRequestWithContext() failed: timed out request should not match ErrRequestCanceled, got %v", err)
+	}
+}
+
+//Transport that counts the requests it forwards
+type countingTransport struct {
+	requests int
+}
+
+func (c *countingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	c.requests++
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestGetHttpClient(t *testing.T) {
+	api := &LatchAPI{}
+	if api.GetHttpClient() != DefaultHttpClient {
+		t.Errorf("GetHttpClient() failed: expected DefaultHttpClient when nothing is configured")
+	}
+
+	proxy_url, _ := url.Parse("http://localhost:3128")
+	api.SetProxy(proxy_url)
+	first_client, second_client := api.GetHttpClient(), api.GetHttpClient()
+	if first_client.Transport == nil || first_client.Transport != second_client.Transport {
+		t.Errorf("GetHttpClient() failed: expected the proxy transport to be reused between requests")
+	}
+
+	transport := &countingTransport{}
+	api.SetTransport(transport)
+	if api.GetHttpClient().Transport != transport {
+		t.Errorf("GetHttpClient() failed: expected the transport to take precedence over the proxy")
+	}
+
+	client := &http.Client{}
+	api.SetHttpClient(client)
+	if api.GetHttpClient() != client {
+		t.Errorf("GetHttpClient() failed: expected the client to take precedence over the transport")
+	}
+}
+
+func TestDoRequestWithTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"operations":{"MyAppID":{"status":"on"}}}}`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	api := &LatchAPI{}
+	api.SetTransport(transport)
+
+	if _, err := api.DoRequest(newTestRequest(server.URL), &LatchStatusResponse{}); err != nil {
+		t.Errorf("DoRequest() failed: %v", err)
+	}
+	if transport.requests != 1 {
+		t.Errorf("DoRequest() failed: expected 1 request through the custom transport, got %d", transport.requests)
+	}
+}
+
+func TestDoRequestErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/1.0/status/MyAccountID" {
+			w.Write([]byte(`{"error":{"code":201,"message":"Account not paired"}}`))
+			return
+		}
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("Down for maintenance"))
+	}))
+	defer server.Close()
+
+	api := &LatchAPI{}
+	if _, err := api.DoRequest(newTestRequest(server.URL), &LatchStatusResponse{}); !errors.Is(err, ErrAccountNotPaired) {
+		t.Errorf("DoRequest() failed: expected ErrAccountNotPaired, got %v", err)
+	}
+
+	request := newTestRequest(server.URL)
+	request.URL.Path = "/api/1.0/history/MyAccountID"
+	_, err := api.DoRequest(request, nil)
+
+	var http_error *LatchHttpError
+	if !errors.As(err, &http_error) {
+		t.Fatalf("DoRequest() failed: expected LatchHttpError, got %v", err)
+	}
+	if http_error.StatusCode != http.StatusServiceUnavailable || http_error.Header.Get("Retry-After") != "10" || string(http_error.Body) != "Down for maintenance" {
+		t.Errorf("DoRequest() failed: unexpected HTTP error %+v", http_error)
+	}
+}
+
+type recordingObserver struct {
+	headers     []string
+	attempts    []int
+	statusCodes []int
+	errors      []error
+}
+
+func (o *recordingObserver) RequestStart(ctx context.Context, request *LatchRequest) context.Context {
+	return ctx
+}
+
+func (o *recordingObserver) AttemptStart(ctx context.Context, request *LatchRequest, attempt int, httpRequest *http.Request) context.Context {
+	httpRequest.Header.Set("X-Trace", "MyTrace")
+	return ctx
+}
+
+func (o *recordingObserver) AttemptEnd(ctx context.Context, request *LatchRequest, attempt int, statusCode int, err error) {
+	o.attempts = append(o.attempts, attempt)
+}
+
+func (o *recordingObserver) RequestEnd(ctx context.Context, request *LatchRequest, statusCode int, err error) {
+	o.statusCodes = append(o.statusCodes, statusCode)
+	o.errors = append(o.errors, err)
+}
+
+func TestObserver(t *testing.T) {
+	observer := &recordingObserver{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		observer.headers = append(observer.headers, r.Header.Get("X-Trace"))
+		switch r.URL.Path {
+		case "/api/1.0/status/MyAccountID":
+			w.Write([]byte(`{"data":{"operations":{"MyAppID":{"status":"on"}}}}`))
+		case "/api/1.0/status/MyUnpairedAccountID":
+			w.Write([]byte(`{"error":{"code":201,"message":"Account not paired"}}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	api := &LatchAPI{}
+	api.SetObserver(observer)
+	for _, path := range []string{"/api/1.0/status/MyAccountID", "/api/1.0/status/MyUnpairedAccountID", "/api/1.0/history/MyAccountID"} {
+		request := newTestRequest(server.URL)
+		request.URL.Path = path
+		api.DoRequest(request, &LatchStatusResponse{})
+	}
+
+	if !reflect.DeepEqual(observer.statusCodes, []int{200, 200, 503}) {
+		t.Errorf("Observer failed: unexpected status codes %v", observer.statusCodes)
+	}
+	if observer.errors[0] != nil || !errors.Is(observer.errors[1], ErrAccountNotPaired) || observer.errors[2] == nil {
+		t.Errorf("Observer failed: unexpected errors %v", observer.errors)
+	}
+	if !reflect.DeepEqual(observer.headers, []string{"MyTrace", "MyTrace", "MyTrace"}) {
+		t.Errorf("Observer failed: expected headers set by the observer to be sent, got %v", observer.headers)
+	}
+
+	if !reflect.DeepEqual(observer.attempts, []int{1, 1, 1}) {
+		t.Errorf("Observer failed: unexpected attempts %v", observer.attempts)
+	}
+
+	api.SetObserver(nil)
+	api.DoRequest(newTestRequest(server.URL), &LatchStatusResponse{})
+	if len(observer.statusCodes) != 3 {
+		t.Errorf("SetObserver() failed: expected observer to be removed")
+	}
+}
+
+func TestObserverRetriesAndSigningErrors(t *testing.T) {
+	var dates []string
+	server := newFailingTestServer(2, http.StatusBadGateway, &dates)
+	defer server.Close()
+
+	//Each request is observed once, with all its attempts
+	observer := &recordingObserver{}
+	api := &LatchAPI{}
+	api.SetObserver(observer)
+	api.SetRetryPolicy(newTestRetryPolicy())
+	if _, err := api.DoRequest(newTestRequest(server.URL), &LatchStatusResponse{}); err != nil {
+		t.Fatalf("DoRequest() failed: unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(observer.attempts, []int{1, 2, 3}) || !reflect.DeepEqual(observer.statusCodes, []int{200}) {
+		t.Errorf("Observer failed: expected 3 attempts of a successful request, got attempts %v and status codes %v", observer.attempts, observer.statusCodes)
+	}
+
+	//Requests that can't be signed have no attempts, but they are observed too
+	observer = &recordingObserver{}
+	api = &LatchAPI{}
+	api.SetObserver(observer)
+	request := newTestRequest(server.URL)
+	request.Signer = &testSigner{err: errors.New("signer unavailable")}
+	api.DoRequest(request, nil)
+	if len(observer.attempts) != 0 || len(observer.errors) != 1 || !errors.Is(observer.errors[0], ErrSigningFailed) {
+		t.Errorf("Observer failed: expected a failed request without attempts, got attempts %v and errors %v", observer.attempts, observer.errors)
+	}
+}
@@ -0,0 +1,232 @@
+package golatch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//Default time that a request to the API made by the cache can take
+const DEFAULT_STATUS_CACHE_TIMEOUT = 30 * time.Second
+
+//In-memory cache for the responses of Status(), OperationStatus() and InstanceStatus()
+//Concurrent requests for the same status with nootp are coalesced into a single request to the API
+//Cached responses are shared between callers and must not be modified
+//Requests without nootp are not coalesced and responses that include a two factor token are never cached
+//(every call must get its own one time password)
+type StatusCache struct {
+	//Time that responses are kept in the cache
+	TTL time.Duration
+	//Specific TTLs for some status values (for example a shorter TTL for LATCH_STATUS_OFF)
+	StatusTTL map[string]time.Duration
+	//If greater than zero, entries that expire within this time are refreshed in the background when they are read
+	RefreshAhead time.Duration
+	//Max time of the requests made by the cache (DEFAULT_STATUS_CACHE_TIMEOUT if 0)
+	//Requests are shared by several callers, so they are not canceled when the caller that started them gives up
+	Timeout time.Duration
+
+	mu        sync.Mutex
+	entries   map[StatusCacheKey]*statusCacheEntry
+	calls     map[StatusCacheKey]*statusCacheCall
+	lastSweep time.Time
+	//Incremented every time the cache is invalidated
+	generation uint64
+}
+
+//Key of the cached status responses
+type StatusCacheKey struct {
+	AccountId   string
+	OperationId string
+	InstanceId  string
+	NoOtp       bool
+	Silent      bool
+	//Serialized extra headers of the request (see LatchRequest.GetSerializedHeaders()), since they may change the response
+	XHeaders string
+}
+
+type statusCacheEntry struct {
+	response *LatchStatusResponse
+	expires  time.Time
+}
+
+//Request to the API in progress, shared by all the callers that ask for the same status
+type statusCacheCall struct {
+	done     chan struct{}
+	response *LatchStatusResponse
+	err      error
+}
+
+//Function used by the cache to get a status from the API
+type statusFetcher func(ctx context.Context) (*LatchStatusResponse, error)
+
+//Returns a new status cache that keeps responses for the time provided
+func NewStatusCache(ttl time.Duration) *StatusCache {
+	return &StatusCache{TTL: ttl}
+}
+
+//Sets the cache used by Status(), OperationStatus() and InstanceStatus() (nil disables caching)
+func (l *Latch) SetStatusCache(cache *StatusCache) {
+	l.StatusCache = cache
+}
+
+//Gets a status from the cache, calling fetch if it's not cached or has expired
+func (c *StatusCache) get(ctx context.Context, key StatusCacheKey, fetch statusFetcher) (*LatchStatusResponse, error) {
+	c.mu.Lock()
+	now := time.Now()
+	if entry, ok := c.entries[key]; ok && now.Before(entry.expires) {
+		//Statuses that may include a one time password are not refreshed (it would be sent to the user for nothing)
+		if c.RefreshAhead > 0 && key.NoOtp && entry.expires.Sub(now) <= c.RefreshAhead && c.calls[key] == nil {
+			c.startFetch(ctx, key, c.startCall(key), fetch)
+		}
+		c.mu.Unlock()
+		return entry.response, nil
+	}
+
+	//Each request for a status that may include a one time password must get its own password, so they are not coalesced
+	if !key.NoOtp {
+		generation := c.generation
+		c.mu.Unlock()
+		return c.fetchUncoalesced(ctx, key, generation, fetch)
+	}
+
+	call, inProgress := c.calls[key]
+	if !inProgress {
+		call = c.startCall(key)
+		c.startFetch(ctx, key, call, fetch)
+	}
+	c.mu.Unlock()
+
+	//Each caller only gives up on its own context, the request goes on for the rest
+	select {
+	case <-call.done:
+		return call.response, call.err
+	case <-ctx.Done():
+		return nil, contextError(ctx, ctx.Err())
+	}
+}
+
+//Gets a status from the API for a single caller and stores it in the cache (if successful and the cache hasn't been invalidated meanwhile)
+func (c *StatusCache) fetchUncoalesced(ctx context.Context, key StatusCacheKey, generation uint64, fetch statusFetcher) (*LatchStatusResponse, error) {
+	response, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.set(key, response)
+	}
+	return response, nil
+}
+
+//Registers a new call in progress for the key provided (must be called with the lock held)
+func (c *StatusCache) startCall(key StatusCacheKey) *statusCacheCall {
+	if c.calls == nil {
+		c.calls = make(map[StatusCacheKey]*statusCacheCall)
+	}
+
+	call := &statusCacheCall{done: make(chan struct{})}
+	c.calls[key] = call
+	return call
+}
+
+//Starts a request to the API in the background, detached from the cancellation of the context of the caller
+//(values like the extra headers are kept) and limited by the timeout of the cache
+func (c *StatusCache) startFetch(ctx context.Context, key StatusCacheKey, call *statusCacheCall, fetch statusFetcher) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DEFAULT_STATUS_CACHE_TIMEOUT
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	go func() {
+		defer cancel()
+		c.fetch(ctx, key, call, fetch)
+	}()
+}
+
+//Gets a status from the API, stores it in the cache (if successful) and notifies the callers waiting for it
+func (c *StatusCache) fetch(ctx context.Context, key StatusCacheKey, call *statusCacheCall, fetch statusFetcher) {
+	call.response, call.err = fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.calls[key] == call {
+		delete(c.calls, key)
+		if call.err == nil {
+			c.set(key, call.response)
+		}
+	}
+	close(call.done)
+}
+
+//Stores a response in the cache (must be called with the lock held)
+func (c *StatusCache) set(key StatusCacheKey, response *LatchStatusResponse) {
+	if response.TwoFactor().Token != "" {
+		return
+	}
+
+	ttl := c.TTL
+	if status_ttl, ok := c.StatusTTL[response.Status()]; ok {
+		ttl = status_ttl
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+	if c.entries == nil {
+		c.entries = make(map[StatusCacheKey]*statusCacheEntry)
+	}
+	c.entries[key] = &statusCacheEntry{response: response, expires: now.Add(ttl)}
+
+	//Remove expired entries from time to time so the cache doesn't grow forever
+	if now.Sub(c.lastSweep) > c.TTL {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+}
+
+//Removes all the cached statuses of an account (including the statuses of its operations)
+//Requests in progress for the account won't be stored in the cache when they finish
+func (c *StatusCache) Invalidate(accountId string) {
+	c.invalidate(func(key StatusCacheKey) bool {
+		return key.AccountId == accountId
+	})
+}
+
+//Removes the cached statuses of an operation of an account
+func (c *StatusCache) InvalidateOperation(accountId string, operationId string) {
+	c.invalidate(func(key StatusCacheKey) bool {
+		return key.AccountId == accountId && key.OperationId == operationId
+	})
+}
+
+//Removes all the cached statuses
+func (c *StatusCache) Clear() {
+	c.invalidate(func(key StatusCacheKey) bool {
+		return true
+	})
+}
+
+func (c *StatusCache) invalidate(match func(key StatusCacheKey) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for key := range c.entries {
+		if match(key) {
+			delete(c.entries, key)
+		}
+	}
+	for key := range c.calls {
+		if match(key) {
+			delete(c.calls, key)
+		}
+	}
+}
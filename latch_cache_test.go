@@ -0,0 +1,233 @@
+package golatch_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/millenc/golatch"
+	"github.com/millenc/golatch/golatchtest"
+)
+
+//Fake Latch API that answers the status requests after the delay provided
+func newSlowStatusServer(delay time.Duration) (*golatchtest.Server, *golatch.Latch, func()) {
+	server := newFakeServer()
+	front := newFrontServer(server, func(w http.ResponseWriter, r *http.Request) bool {
+		time.Sleep(delay)
+		return true
+	})
+	latch := newFrontLatch(server, front)
+	latch.SetStatusCache(golatch.NewStatusCache(time.Minute))
+	return server, latch, func() {
+		front.Close()
+		server.Close()
+	}
+}
+
+func TestStatusCache(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	latch := server.Latch("MyAppID")
+	latch.SetStatusCache(golatch.NewStatusCache(time.Minute))
+
+	for i := 0; i < 3; i++ {
+		if response, err := latch.Status("MyAccountID", true, false); err != nil || response.Status() != golatch.LATCH_STATUS_ON {
+			t.Fatalf("Status() failed: expected status on, got %v (error %v)", response, err)
+		}
+	}
+	if got := statusCalls(server); got != 1 {
+		t.Errorf("Status() failed: expected 1 request to the API, got %d", got)
+	}
+
+	//Different flags and operations are cached separately
+	latch.Status("MyAccountID", false, false)
+	latch.OperationStatus("MyAccountID", "MyOperationID", true, false)
+	if got := statusCalls(server); got != 3 {
+		t.Errorf("Status() failed: expected 3 requests to the API, got %d", got)
+	}
+
+	//Locking the account invalidates its statuses
+	if err := latch.Lock("MyAccountID"); err != nil {
+		t.Fatalf("Lock() failed: unexpected error %v", err)
+	}
+	if response, err := latch.Status("MyAccountID", true, false); err != nil || response.Status() != golatch.LATCH_STATUS_OFF {
+		t.Errorf("Status() failed: expected status off after Lock(), got %v (error %v)", response, err)
+	}
+	if got := statusCalls(server); got != 4 {
+		t.Errorf("Status() failed: expected 4 requests to the API, got %d", got)
+	}
+}
+
+func TestStatusCacheStatusTTL(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	server.SetStatus("MyAppID", "MyAccountID", golatch.LATCH_STATUS_OFF)
+
+	cache := golatch.NewStatusCache(time.Minute)
+	cache.StatusTTL = map[string]time.Duration{golatch.LATCH_STATUS_OFF: 20 * time.Millisecond}
+	latch := server.Latch("MyAppID")
+	latch.SetStatusCache(cache)
+
+	latch.Status("MyAccountID", true, false)
+	latch.Status("MyAccountID", true, false)
+	time.Sleep(30 * time.Millisecond)
+	latch.Status("MyAccountID", true, false)
+
+	if got := statusCalls(server); got != 2 {
+		t.Errorf("Status() failed: expected off status to expire after 20ms (2 requests), got %d requests", got)
+	}
+}
+
+func TestStatusCacheCoalescing(t *testing.T) {
+	server, latch, stop := newSlowStatusServer(50 * time.Millisecond)
+	defer stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if response, err := latch.OperationStatus("MyAccountID", "MyOperationID", true, true); err != nil || response.Status() != golatch.LATCH_STATUS_ON {
+				t.Errorf("OperationStatus() failed: expected status on, got %v (error %v)", response, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := statusCalls(server); got != 1 {
+		t.Errorf("OperationStatus() failed: expected concurrent misses to be coalesced in 1 request, got %d", got)
+	}
+}
+
+func TestStatusCacheNoCoalescingWithOtp(t *testing.T) {
+	server, latch, stop := newSlowStatusServer(50 * time.Millisecond)
+	defer stop()
+
+	//Each request without nootp may get its own one time password
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latch.Status("MyAccountID", false, false)
+		}()
+	}
+	wg.Wait()
+	if got := statusCalls(server); got != 3 {
+		t.Errorf("Status() failed: expected 3 requests without nootp, got %d", got)
+	}
+
+	//Responses without two factor token are still cached
+	latch.Status("MyAccountID", false, false)
+	if got := statusCalls(server); got != 3 {
+		t.Errorf("Status() failed: expected cached response, got %d requests", got)
+	}
+}
+
+func TestStatusCacheCanceledCaller(t *testing.T) {
+	server, latch, stop := newSlowStatusServer(50 * time.Millisecond)
+	defer stop()
+
+	//The caller that starts the request gives up, but the ones waiting for it still get the response
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	first_err := make(chan error)
+	go func() {
+		_, err := latch.StatusWithContext(ctx, "MyAccountID", true, false)
+		first_err <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	if response, err := latch.Status("MyAccountID", true, false); err != nil || response.Status() != golatch.LATCH_STATUS_ON {
+		t.Errorf("Status() failed: expected status on, got %v (error %v)", response, err)
+	}
+	if err := <-first_err; !errors.Is(err, golatch.ErrRequestTimeout) {
+		t.Errorf("StatusWithContext() failed: expected ErrRequestTimeout, got %v", err)
+	}
+	if got := statusCalls(server); got != 1 {
+		t.Errorf("Status() failed: expected 1 request to the API, got %d", got)
+	}
+}
+
+func TestStatusCacheTwoFactor(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	server.AddOperation("MyAppID", "MyAppID", "MyTwoFactorOperationID", "My Two Factor Operation").TwoFactor = golatch.MANDATORY
+	latch := server.Latch("MyAppID")
+	latch.SetStatusCache(golatch.NewStatusCache(time.Minute))
+
+	tokens := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		response, err := latch.OperationStatus("MyAccountID", "MyTwoFactorOperationID", false, false)
+		if err != nil || response.TwoFactor().Token == "" {
+			t.Fatalf("OperationStatus() failed: expected two factor token, got %v (error %v)", response, err)
+		}
+		tokens[response.TwoFactor().Token] = true
+	}
+	if got := statusCalls(server); got != 2 || len(tokens) != 2 {
+		t.Errorf("OperationStatus() failed: expected responses with two factor token not to be cached (2 requests and tokens), got %d requests and %d tokens", got, len(tokens))
+	}
+}
+
+func TestStatusCacheRefreshAhead(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	latch := server.Latch("MyAppID")
+	latch.SetStatusCache(&golatch.StatusCache{TTL: 100 * time.Millisecond, RefreshAhead: 80 * time.Millisecond})
+
+	latch.Status("MyAccountID", true, false)
+	time.Sleep(30 * time.Millisecond)
+	server.SetStatus("MyAppID", "MyAccountID", golatch.LATCH_STATUS_OFF)
+
+	//Served from the cache, but refreshed in the background
+	if response, _ := latch.Status("MyAccountID", true, false); response.Status() != golatch.LATCH_STATUS_ON {
+		t.Errorf("Status() failed: expected cached status on, got %q", response.Status())
+	}
+	time.Sleep(30 * time.Millisecond)
+	if response, _ := latch.Status("MyAccountID", true, false); response.Status() != golatch.LATCH_STATUS_OFF {
+		t.Errorf("Status() failed: expected refreshed status off, got %q", response.Status())
+	}
+	if got := statusCalls(server); got != 2 {
+		t.Errorf("Status() failed: expected 2 requests to the API, got %d", got)
+	}
+}
+
+func TestStatusCacheRefreshAheadTimeout(t *testing.T) {
+	//The API hangs once hang is set
+	var hang atomic.Bool
+	hung := make(chan struct{})
+	server := newFakeServer()
+	defer server.Close()
+	front := newFrontServer(server, func(w http.ResponseWriter, r *http.Request) bool {
+		if hang.Load() {
+			<-hung
+			return false
+		}
+		return true
+	})
+	defer front.Close()
+	defer close(hung)
+
+	latch := newFrontLatch(server, front)
+	latch.SetStatusCache(&golatch.StatusCache{TTL: 50 * time.Millisecond, RefreshAhead: 40 * time.Millisecond, Timeout: 20 * time.Millisecond})
+	latch.Status("MyAccountID", true, false)
+
+	//The refresh hangs, but it's limited by the timeout of the cache so later callers don't wait for it forever
+	hang.Store(true)
+	latch.Status("MyAccountID", true, false)
+	time.Sleep(60 * time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		_, err := latch.Status("MyAccountID", true, false)
+		done <- err
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Status() failed: expected the hung refresh to time out")
+	}
+}
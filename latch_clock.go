@@ -0,0 +1,85 @@
+package golatch
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+//Source of the current time used to date (and sign) requests
+type Clock interface {
+	Now() time.Time
+}
+
+//Clock that returns the system time
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+//Clock used when none has been set
+var SystemClock Clock = systemClock{}
+
+//Clock that always returns the same time (useful to get deterministic signatures in tests)
+type FixedClock time.Time
+
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+//Sets the clock used to date requests (nil means the system clock)
+func (l *LatchAPI) SetClock(clock Clock) {
+	l.Clock = clock
+}
+
+//Enables or disables the automatic correction of the clock skew
+//When enabled, the offset between the local clock and the Date header of the API responses is measured
+//and used to correct the date of the following requests
+func (l *LatchAPI) SetClockSkewCorrection(enabled bool) {
+	l.CorrectClockSkew = enabled
+	if !enabled {
+		atomic.StoreInt64(&l.clockSkew, 0)
+	}
+}
+
+//Gets the last measured offset between the clock of the API and the local clock (positive if the local clock is behind)
+//It's always 0 unless the clock skew correction is enabled
+func (l *LatchAPI) ClockSkew() time.Duration {
+	return time.Duration(atomic.LoadInt64(&l.clockSkew))
+}
+
+//Gets the current time used to date requests, corrected with the measured clock skew (if enabled)
+func (l *LatchAPI) Now() time.Time {
+	if l.CorrectClockSkew {
+		return l.getClock().Now().Add(l.ClockSkew())
+	}
+	return l.getClock().Now()
+}
+
+//Gets the clock set with SetClock() or the system clock
+func (l *LatchAPI) getClock() Clock {
+	if l.Clock == nil {
+		return SystemClock
+	}
+	return l.Clock
+}
+
+//Measures the clock skew using the Date header of a response
+//Offsets under a second are ignored since the header doesn't have more precision
+func (l *LatchAPI) measureClockSkew(response *http.Response) {
+	if !l.CorrectClockSkew {
+		return
+	}
+
+	date, err := http.ParseTime(response.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+
+	skew := date.Sub(l.getClock().Now())
+	if skew > -time.Second && skew < time.Second {
+		skew = 0
+	}
+	atomic.StoreInt64(&l.clockSkew, int64(skew))
+}
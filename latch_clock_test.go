@@ -0,0 +1,62 @@
+package golatch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFixedClockSignature(t *testing.T) {
+	var got_header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got_header = r.Header.Get(API_AUTHORIZATION_HEADER_NAME)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+	latch.SetClock(FixedClock(example_date))
+
+	if err := latch.Unpair("MyAccountID"); err != nil {
+		t.Fatalf("Unpair() failed: unexpected error %v", err)
+	}
+
+	request_url, _ := latch.GetLatchURL("unpair/MyAccountID")
+	expected_header := NewLatchRequest("MyAppID", "MySecretKey", HTTP_METHOD_GET, request_url, nil, nil, example_date).GetAuthorizationHeader()
+	if got_header != expected_header {
+		t.Errorf("SetClock() failed: expected Authorization header %q, got %q", expected_header, got_header)
+	}
+}
+
+func TestClockSkewCorrection(t *testing.T) {
+	server_time := time.Date(2030, time.January, 1, 12, 0, 0, 0, time.UTC)
+	var got_dates []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got_dates = append(got_dates, r.Header.Get(API_DATE_HEADER_NAME))
+		w.Header().Set("Date", server_time.Format(http.TimeFormat))
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+	latch.SetClock(FixedClock(server_time.Add(-time.Hour)))
+	latch.SetClockSkewCorrection(true)
+
+	latch.Lock("MyAccountID")
+	if latch.ClockSkew() != time.Hour {
+		t.Errorf("ClockSkew() failed: expected 1h, got %v", latch.ClockSkew())
+	}
+
+	latch.Unlock("MyAccountID")
+	if got_dates[0] != "2030-01-01 11:00:00" || got_dates[1] != "2030-01-01 12:00:00" {
+		t.Errorf("SetClockSkewCorrection() failed: expected the second request to be corrected, got %q", got_dates)
+	}
+
+	latch.SetClockSkewCorrection(false)
+	if latch.ClockSkew() != 0 || !latch.Now().Equal(server_time.Add(-time.Hour)) {
+		t.Errorf("SetClockSkewCorrection() failed: expected no correction once disabled, got %v", latch.ClockSkew())
+	}
+}
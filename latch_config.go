@@ -0,0 +1,383 @@
+package golatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//Environment variables read by ConfigFromEnv() and LoadConfig()
+const (
+	ENV_APP_ID          = "LATCH_APP_ID"
+	ENV_SECRET_KEY      = "LATCH_SECRET_KEY"
+	ENV_USER_ID         = "LATCH_USER_ID"
+	ENV_USER_SECRET_KEY = "LATCH_USER_SECRET_KEY"
+	ENV_API_URL         = "LATCH_API_URL"
+	ENV_API_VERSION     = "LATCH_API_VERSION"
+	ENV_PROXY           = "LATCH_PROXY"
+	ENV_TIMEOUT         = "LATCH_TIMEOUT"
+	ENV_PROFILE         = "LATCH_PROFILE"
+	ENV_CONFIG_FILE     = "LATCH_CONFIG_FILE"
+)
+
+//Profile used when none is provided
+const DEFAULT_PROFILE = "default"
+
+//Config file used when none is provided (relative to the home directory)
+const DEFAULT_CONFIG_FILE = ".latch/config.json"
+
+//Error returned when the credentials needed by a client are missing from the configuration
+var ErrMissingCredentials = errors.New("missing credentials")
+
+//Error returned when the requested profile is not in the config file
+var ErrProfileNotFound = errors.New("profile not found")
+
+//Configuration of a client
+type Config struct {
+	AppID         string
+	SecretKey     string
+	UserID        string
+	UserSecretKey string
+	APIURL        string
+	APIVersion    string
+	Proxy         string
+	Timeout       time.Duration
+}
+
+//Invalid or missing value in the configuration
+type ConfigError struct {
+	Source string //environment variable or config file (and profile) where the value was found
+	Field  string
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("invalid Latch configuration (%s): %v", e.Source, e.Err)
+	}
+	return fmt.Sprintf("invalid Latch configuration (%s): %s: %v", e.Source, e.Field, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+//Profile of the config file
+type configProfile struct {
+	AppID         string `json:"app_id"`
+	SecretKey     string `json:"secret_key"`
+	UserID        string `json:"user_id"`
+	UserSecretKey string `json:"user_secret_key"`
+	APIURL        string `json:"api_url"`
+	APIVersion    string `json:"api_version"`
+	Proxy         string `json:"proxy"`
+	Timeout       string `json:"timeout"`
+}
+
+//Reads the configuration from the LATCH_* environment variables
+func ConfigFromEnv() (*Config, error) {
+	c := &Config{
+		AppID:         os.Getenv(ENV_APP_ID),
+		SecretKey:     os.Getenv(ENV_SECRET_KEY),
+		UserID:        os.Getenv(ENV_USER_ID),
+		UserSecretKey: os.Getenv(ENV_USER_SECRET_KEY),
+		APIURL:        os.Getenv(ENV_API_URL),
+		APIVersion:    os.Getenv(ENV_API_VERSION),
+		Proxy:         os.Getenv(ENV_PROXY),
+	}
+
+	if err := validateURL(ENV_API_URL, "api_url", c.APIURL, validateAPIURL); err != nil {
+		return nil, err
+	}
+	if err := validateAPIVersion(ENV_API_VERSION, c.APIVersion); err != nil {
+		return nil, err
+	}
+	if err := validateURL(ENV_PROXY, "proxy", c.Proxy, validateProxy); err != nil {
+		return nil, err
+	}
+	timeout, err := parseTimeout(ENV_TIMEOUT, os.Getenv(ENV_TIMEOUT))
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout = timeout
+
+	return c, nil
+}
+
+//Reads a profile of a config file. The config file is a JSON document with the profiles by name:
+//
+//	{"profiles": {"prod": {"app_id": "...", "secret_key": "...", "api_url": "...", "api_version": "3.0", "proxy": "...", "timeout": "10s"}}}
+func ConfigFromFile(path string, profile string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Profiles map[string]configProfile `json:"profiles"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&file); err != nil {
+		return nil, &ConfigError{Source: path, Err: err}
+	}
+
+	p, ok := file.Profiles[profile]
+	source := fmt.Sprintf("%s, profile %q", path, profile)
+	if !ok {
+		return nil, &ConfigError{Source: source, Err: ErrProfileNotFound}
+	}
+	if err := validateURL(source, "api_url", p.APIURL, validateAPIURL); err != nil {
+		return nil, err
+	}
+	if err := validateAPIVersion(source, p.APIVersion); err != nil {
+		return nil, err
+	}
+	if err := validateURL(source, "proxy", p.Proxy, validateProxy); err != nil {
+		return nil, err
+	}
+	timeout, err := parseTimeout(source, p.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		AppID:         p.AppID,
+		SecretKey:     p.SecretKey,
+		UserID:        p.UserID,
+		UserSecretKey: p.UserSecretKey,
+		APIURL:        p.APIURL,
+		APIVersion:    p.APIVersion,
+		Proxy:         p.Proxy,
+		Timeout:       timeout,
+	}, nil
+}
+
+//Loads the configuration from a profile of a config file, overridden by the environment variables
+//If path is empty LATCH_CONFIG_FILE is used, or ~/.latch/config.json if it exists
+//If profile is empty LATCH_PROFILE is used, or the "default" profile if it exists
+func LoadConfig(path string, profile string) (*Config, error) {
+	requiredFile, requiredProfile := true, true
+	if path == "" {
+		path = os.Getenv(ENV_CONFIG_FILE)
+	}
+	if path == "" {
+		requiredFile = false
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, DEFAULT_CONFIG_FILE)
+		}
+	}
+	if profile == "" {
+		profile = os.Getenv(ENV_PROFILE)
+	}
+	if profile == "" {
+		profile, requiredProfile = DEFAULT_PROFILE, false
+	}
+
+	config := &Config{}
+	if path != "" {
+		fileConfig, err := ConfigFromFile(path, profile)
+		switch {
+		case err == nil:
+			config = fileConfig
+		case errors.Is(err, os.ErrNotExist) && !requiredFile && !requiredProfile:
+		case errors.Is(err, os.ErrNotExist) && !requiredFile:
+			return nil, &ConfigError{Source: fmt.Sprintf("profile %q", profile), Err: ErrProfileNotFound}
+		case errors.Is(err, ErrProfileNotFound) && !requiredProfile:
+		default:
+			return nil, err
+		}
+	}
+
+	envConfig, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	config.Merge(envConfig)
+
+	return config, nil
+}
+
+//Overrides the values of the configuration with the ones set in other
+func (c *Config) Merge(other *Config) {
+	merge := func(value *string, override string) {
+		if override != "" {
+			*value = override
+		}
+	}
+	merge(&c.AppID, other.AppID)
+	merge(&c.SecretKey, other.SecretKey)
+	merge(&c.UserID, other.UserID)
+	merge(&c.UserSecretKey, other.UserSecretKey)
+	merge(&c.APIURL, other.APIURL)
+	merge(&c.APIVersion, other.APIVersion)
+	merge(&c.Proxy, other.Proxy)
+	if other.Timeout != 0 {
+		c.Timeout = other.Timeout
+	}
+}
+
+//Configures the endpoint, version, proxy and timeout of an API client
+func (c *Config) Apply(api *LatchAPI) error {
+	if c.APIURL != "" {
+		if err := api.SetAPIURL(c.APIURL); err != nil {
+			return &ConfigError{Source: "config", Field: "api_url", Err: err}
+		}
+	}
+	if c.APIVersion != "" {
+		if err := validateAPIVersion("config", c.APIVersion); err != nil {
+			return err
+		}
+		api.SetAPIVersion(c.APIVersion)
+	}
+
+	var proxy *url.URL
+	if c.Proxy != "" {
+		var err error
+		if proxy, err = parseProxy(c.Proxy); err != nil {
+			return &ConfigError{Source: "config", Field: "proxy", Err: err}
+		}
+		api.SetProxy(proxy)
+	}
+
+	if c.Timeout != 0 {
+		if c.Timeout < 0 {
+			return &ConfigError{Source: "config", Field: "timeout", Err: fmt.Errorf("negative timeout %s", c.Timeout)}
+		}
+		client := &http.Client{Timeout: c.Timeout}
+		if proxy != nil {
+			client.Transport = getProxyTransport(proxy)
+		}
+		api.SetHttpClient(client)
+	}
+
+	return nil
+}
+
+//Constructs a new Latch struct from a configuration (the application credentials are required)
+func NewLatchFromConfig(c *Config) (*Latch, error) {
+	if c.AppID == "" || c.SecretKey == "" {
+		return nil, &ConfigError{Source: "config", Field: "app_id/secret_key", Err: ErrMissingCredentials}
+	}
+
+	latch := NewLatch(c.AppID, c.SecretKey)
+	if err := c.Apply(&latch.LatchAPI); err != nil {
+		return nil, err
+	}
+	return latch, nil
+}
+
+//Constructs a new LatchUser struct from a configuration (the user credentials are required)
+func NewLatchUserFromConfig(c *Config) (*LatchUser, error) {
+	if c.UserID == "" || c.UserSecretKey == "" {
+		return nil, &ConfigError{Source: "config", Field: "user_id/user_secret_key", Err: ErrMissingCredentials}
+	}
+
+	latch := NewLatchUser(c.UserID, c.UserSecretKey)
+	if err := c.Apply(&latch.LatchAPI); err != nil {
+		return nil, err
+	}
+	return latch, nil
+}
+
+//Constructs a new Latch struct configured with the LATCH_* environment variables
+func NewLatchFromEnv() (*Latch, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewLatchFromConfig(config)
+}
+
+//Constructs a new LatchUser struct configured with the LATCH_* environment variables
+func NewLatchUserFromEnv() (*LatchUser, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewLatchUserFromConfig(config)
+}
+
+//Constructs a new Latch struct configured with a profile of the config file (see LoadConfig())
+func NewLatchFromProfile(profile string) (*Latch, error) {
+	config, err := LoadConfig("", profile)
+	if err != nil {
+		return nil, err
+	}
+	return NewLatchFromConfig(config)
+}
+
+//Constructs a new LatchUser struct configured with a profile of the config file (see LoadConfig())
+func NewLatchUserFromProfile(profile string) (*LatchUser, error) {
+	config, err := LoadConfig("", profile)
+	if err != nil {
+		return nil, err
+	}
+	return NewLatchUserFromConfig(config)
+}
+
+//Parses the URL of a proxy
+func parseProxy(proxy string) (*url.URL, error) {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+	}
+	if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" && proxyURL.Scheme != "socks5" {
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https or socks5", proxy)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", proxy)
+	}
+	return proxyURL, nil
+}
+
+func validateAPIURL(value string) error {
+	_, err := parseAPIURL(value)
+	return err
+}
+
+func validateProxy(value string) error {
+	_, err := parseProxy(value)
+	return err
+}
+
+//Validates an optional URL of the configuration
+func validateURL(source string, field string, value string, validate func(string) error) error {
+	if value == "" {
+		return nil
+	}
+	if err := validate(value); err != nil {
+		return &ConfigError{Source: source, Field: field, Err: err}
+	}
+	return nil
+}
+
+//Validates an optional version of the API of the configuration (it must be one of the registered versions)
+func validateAPIVersion(source string, version string) error {
+	if _, ok := GetAPIVersionSpec(version); version != "" && !ok {
+		return &ConfigError{Source: source, Field: "api_version", Err: fmt.Errorf("unsupported version %q (supported versions: %s)", version, strings.Join(APIVersions(), ", "))}
+	}
+	return nil
+}
+
+//Parses an optional timeout of the configuration (like "10s")
+func parseTimeout(source string, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err == nil && timeout < 0 {
+		err = fmt.Errorf("negative timeout %s", value)
+	}
+	if err != nil {
+		return 0, &ConfigError{Source: source, Field: "timeout", Err: err}
+	}
+	return timeout, nil
+}
@@ -0,0 +1,170 @@
+package golatch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testConfigFile = `{
+	"profiles": {
+		"default": {"app_id": "MyAppID", "secret_key": "MySecretKey"},
+		"staging": {
+			"app_id": "MyStagingAppID",
+			"secret_key": "MyStagingSecretKey",
+			"user_id": "MyStagingUserID",
+			"user_secret_key": "MyStagingUserSecretKey",
+			"api_url": "https://latch.staging.example.com",
+			"api_version": "3.0",
+			"proxy": "http://proxy.example.com:3128",
+			"timeout": "5s"
+		},
+		"broken": {"app_id": "MyAppID", "timeout": "soon"}
+	}
+}`
+
+//Writes a config file in a temporary directory and clears the LATCH_* environment variables
+func writeTestConfig(t *testing.T, content string) string {
+	for _, name := range []string{ENV_APP_ID, ENV_SECRET_KEY, ENV_USER_ID, ENV_USER_SECRET_KEY, ENV_API_URL, ENV_API_VERSION, ENV_PROXY, ENV_TIMEOUT, ENV_PROFILE, ENV_CONFIG_FILE} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	writeTestConfig(t, "")
+	t.Setenv(ENV_APP_ID, "MyAppID")
+	t.Setenv(ENV_SECRET_KEY, "MySecretKey")
+	t.Setenv(ENV_API_URL, "https://latch.example.com")
+	t.Setenv(ENV_TIMEOUT, "3s")
+
+	latch, err := NewLatchFromEnv()
+	if err != nil {
+		t.Fatalf("NewLatchFromEnv() failed: unexpected error %v", err)
+	}
+	if latch.AppID != "MyAppID" || latch.SecretKey != "MySecretKey" || latch.APIURL != "https://latch.example.com" {
+		t.Errorf("NewLatchFromEnv() failed: unexpected configuration %+v", latch)
+	}
+	if latch.GetHttpClient().Timeout != 3*time.Second {
+		t.Errorf("NewLatchFromEnv() failed: expected timeout of 3s, got %v", latch.GetHttpClient().Timeout)
+	}
+
+	if _, err := NewLatchUserFromEnv(); !errors.Is(err, ErrMissingCredentials) {
+		t.Errorf("NewLatchUserFromEnv() failed: expected ErrMissingCredentials, got %v", err)
+	}
+
+	t.Setenv(ENV_PROXY, "ftp://proxy.example.com")
+	var config_error *ConfigError
+	if _, err := ConfigFromEnv(); !errors.As(err, &config_error) || config_error.Source != ENV_PROXY {
+		t.Errorf("ConfigFromEnv() failed: expected error for %s, got %v", ENV_PROXY, err)
+	}
+	t.Setenv(ENV_PROXY, "")
+	t.Setenv(ENV_API_VERSION, "2.5")
+	if _, err := ConfigFromEnv(); !errors.As(err, &config_error) || config_error.Field != "api_version" {
+		t.Errorf("ConfigFromEnv() failed: expected error for an unsupported API version, got %v", err)
+	}
+	t.Setenv(ENV_API_VERSION, "")
+	t.Setenv(ENV_TIMEOUT, "-1s")
+	if _, err := ConfigFromEnv(); !errors.As(err, &config_error) || config_error.Field != "timeout" {
+		t.Errorf("ConfigFromEnv() failed: expected error for a negative timeout, got %v", err)
+	}
+}
+
+func TestConfigFromFile(t *testing.T) {
+	path := writeTestConfig(t, testConfigFile)
+
+	config, err := ConfigFromFile(path, "staging")
+	if err != nil {
+		t.Fatalf("ConfigFromFile() failed: unexpected error %v", err)
+	}
+	expected := Config{
+		AppID:         "MyStagingAppID",
+		SecretKey:     "MyStagingSecretKey",
+		UserID:        "MyStagingUserID",
+		UserSecretKey: "MyStagingUserSecretKey",
+		APIURL:        "https://latch.staging.example.com",
+		APIVersion:    API_VERSION_3_0,
+		Proxy:         "http://proxy.example.com:3128",
+		Timeout:       5 * time.Second,
+	}
+	if *config != expected {
+		t.Errorf("ConfigFromFile() failed: expected %+v, got %+v", expected, *config)
+	}
+
+	latch, err := NewLatchUserFromConfig(config)
+	if err != nil {
+		t.Fatalf("NewLatchUserFromConfig() failed: unexpected error %v", err)
+	}
+	if latch.Proxy == nil || latch.Proxy.Host != "proxy.example.com:3128" || latch.GetHttpClient().Timeout != 5*time.Second || latch.GetAPIVersion() != API_VERSION_3_0 {
+		t.Errorf("NewLatchUserFromConfig() failed: expected proxy, timeout and API version to be set, got %+v", latch.LatchAPI)
+	}
+
+	if _, err := ConfigFromFile(path, "prod"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("ConfigFromFile() failed: expected ErrProfileNotFound, got %v", err)
+	}
+	var config_error *ConfigError
+	if _, err := ConfigFromFile(path, "broken"); !errors.As(err, &config_error) || config_error.Field != "timeout" {
+		t.Errorf("ConfigFromFile() failed: expected error for an invalid timeout, got %v", err)
+	}
+	if _, err := ConfigFromFile(writeTestConfig(t, `{"profiles": {"default": {"app": "MyAppID"}}}`), "default"); !errors.As(err, &config_error) {
+		t.Errorf("ConfigFromFile() failed: expected error for unknown fields, got %v", err)
+	}
+	if _, err := ConfigFromFile(writeTestConfig(t, `{"profiles": {"default": {"api_url": "latch.example.com"}}}`), "default"); !errors.As(err, &config_error) || config_error.Field != "api_url" {
+		t.Errorf("ConfigFromFile() failed: expected error for an invalid API URL, got %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeTestConfig(t, testConfigFile)
+
+	//No config file: only the environment is used
+	t.Setenv(ENV_APP_ID, "MyEnvAppID")
+	if config, err := LoadConfig("", ""); err != nil || config.AppID != "MyEnvAppID" {
+		t.Errorf("LoadConfig() failed: expected configuration from the environment, got %+v (error %v)", config, err)
+	}
+	if _, err := LoadConfig("", "staging"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("LoadConfig() failed: expected ErrProfileNotFound for a missing profile, got %v", err)
+	}
+	if _, err := LoadConfig(path+".missing", ""); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadConfig() failed: expected error for a missing config file, got %v", err)
+	}
+
+	//Environment variables override the profile
+	t.Setenv(ENV_CONFIG_FILE, path)
+	config, err := LoadConfig("", "")
+	if err != nil || config.AppID != "MyEnvAppID" || config.SecretKey != "MySecretKey" {
+		t.Errorf("LoadConfig() failed: expected default profile overridden by the environment, got %+v (error %v)", config, err)
+	}
+	t.Setenv(ENV_APP_ID, "")
+	t.Setenv(ENV_PROFILE, "staging")
+	if config, err := LoadConfig("", ""); err != nil || config.AppID != "MyStagingAppID" {
+		t.Errorf("LoadConfig() failed: expected profile set in %s, got %+v (error %v)", ENV_PROFILE, config, err)
+	}
+	if latch, err := NewLatchFromProfile("default"); err != nil || latch.AppID != "MyAppID" {
+		t.Errorf("NewLatchFromProfile() failed: expected default profile, got %v (error %v)", latch, err)
+	}
+	if _, err := NewLatchUserFromProfile("default"); !errors.Is(err, ErrMissingCredentials) {
+		t.Errorf("NewLatchUserFromProfile() failed: expected ErrMissingCredentials, got %v", err)
+	}
+
+	//The default config file is optional, as is its default profile
+	t.Setenv(ENV_CONFIG_FILE, "")
+	t.Setenv(ENV_PROFILE, "")
+	home := os.Getenv("HOME")
+	os.MkdirAll(filepath.Join(home, ".latch"), 0700)
+	os.WriteFile(filepath.Join(home, DEFAULT_CONFIG_FILE), []byte(`{"profiles": {"local": {"app_id": "MyLocalAppID"}}}`), 0600)
+	if config, err := LoadConfig("", ""); err != nil || config.AppID != "" {
+		t.Errorf("LoadConfig() failed: expected empty configuration, got %+v (error %v)", config, err)
+	}
+	if config, err := LoadConfig("", "local"); err != nil || config.AppID != "MyLocalAppID" {
+		t.Errorf("LoadConfig() failed: expected profile of the default config file, got %+v (error %v)", config, err)
+	}
+}
@@ -1,6 +1,40 @@
 package golatch
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+//Errors returned when a request is aborted through its context
+var (
+	ErrRequestCanceled = errors.New("Latch request canceled")
+	ErrRequestTimeout  = errors.New("Latch request timed out")
+)
+
+//Errors returned by the Latch API, as documented in the official API reference
+//Use errors.Is() to check for them: the comparison is done by error code, so the message returned by the API doesn't matter
+var (
+	ErrInvalidAuthorizationHeader = &LatchError{Code: 101, Message: "Invalid Authorization header format"}
+	ErrInvalidSignature           = &LatchError{Code: 102, Message: "Invalid application signature"}
+	ErrAuthorizationExpired       = &LatchError{Code: 103, Message: "Authorization header has expired"}
+	ErrSubscriptionRequired       = &LatchError{Code: 108, Message: "Feature not available for this subscription"}
+	ErrBadRequest                 = &LatchError{Code: 109, Message: "Bad request"}
+	ErrAccountNotPaired           = &LatchError{Code: 201, Message: "Account not paired"}
+	ErrInvalidAccountName         = &LatchError{Code: 202, Message: "Invalid account name"}
+	ErrAlreadyPaired              = &LatchError{Code: 205, Message: "Account and application already paired"}
+	ErrInvalidToken               = &LatchError{Code: 206, Message: "Token not found or expired"}
+	ErrOperationNotFound          = &LatchError{Code: 301, Message: "Operation not found"}
+	ErrMissingParameter           = &LatchError{Code: 401, Message: "Missing parameter in API call"}
+	ErrInvalidParameter           = &LatchError{Code: 402, Message: "Invalid parameter value"}
+)
+
+//Errors returned by the TOTP endpoints (API version 3.0)
+var (
+	ErrTotpNotFound    = &LatchError{Code: 307, Message: "TOTP not found"}
+	ErrInvalidTotpCode = &LatchError{Code: 308, Message: "Invalid TOTP code"}
+	ErrTotpCodeExpired = &LatchError{Code: 309, Message: "TOTP code expired"}
+)
 
 type LatchError struct {
 	Code    int32  `json:"code"`
@@ -12,7 +46,30 @@ func (e *LatchError) Error() string {
 	return fmt.Sprintf("Latch Error: [%d] %s", e.Code, e.Message)
 }
 
+//Two Latch errors are considered the same if they have the same error code (used by errors.Is())
+func (e *LatchError) Is(target error) bool {
+	t, ok := target.(*LatchError)
+	return ok && t.Code == e.Code
+}
+
 //Constructs a new error
 func NewLatchError(code int32, message string) error {
 	return &LatchError{Code: code, Message: message}
 }
+
+//Error returned when the API answers with an HTTP status code other than 200
+type LatchHttpError struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+//Implementation of the error interface
+func (e *LatchHttpError) Error() string {
+	return fmt.Sprintf("HTTP error [%d] body: %s", e.StatusCode, e.Body)
+}
+
+//Returns true if the error was caused by a problem on the server side (5xx status codes)
+func (e *LatchHttpError) IsServerError() bool {
+	return e.StatusCode >= 500 && e.StatusCode <= 599
+}
@@ -1,6 +1,8 @@
 package golatch
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -20,3 +22,33 @@ func TestLatchErrorError(t *testing.T) {
 		t.Errorf("NewLatchError() failed")
 	}
 }
+
+func TestLatchErrorIs(t *testing.T) {
+	var err error = fmt.Errorf("pairing failed: %w", NewLatchError(205, "Already paired"))
+
+	if !errors.Is(err, ErrAlreadyPaired) {
+		t.Errorf("LatchError.Is() failed: expected error to match ErrAlreadyPaired")
+	}
+	if errors.Is(err, ErrAccountNotPaired) {
+		t.Errorf("LatchError.Is() failed: error should not match ErrAccountNotPaired")
+	}
+
+	var latch_error *LatchError
+	if !errors.As(err, &latch_error) || latch_error.Message != "Already paired" {
+		t.Errorf("LatchError.Is() failed: expected errors.As() to return the original error, got %v", latch_error)
+	}
+}
+
+func TestLatchHttpError(t *testing.T) {
+	err := &LatchHttpError{StatusCode: 503, Body: []byte("Service Unavailable")}
+
+	if err.Error() != "HTTP error [503] body: Service Unavailable" {
+		t.Errorf("LatchHttpError.Error() failed: got %q", err.Error())
+	}
+	if !err.IsServerError() {
+		t.Errorf("LatchHttpError.IsServerError() failed: expected true for status code 503")
+	}
+	if (&LatchHttpError{StatusCode: 404}).IsServerError() {
+		t.Errorf("LatchHttpError.IsServerError() failed: expected false for status code 404")
+	}
+}
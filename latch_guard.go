@@ -0,0 +1,193 @@
+package golatch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+//Verdict of a Guard check
+type Verdict int
+
+const (
+	//The status couldn't be determined (for example the account is not paired), the caller must decide
+	VerdictUnknown Verdict = iota
+	//The latch is on (or the policy allows the access while Latch is unavailable)
+	VerdictAllow
+	//The latch is off (or the policy denies the access while Latch is unavailable)
+	VerdictDeny
+	//The latch is on but Latch sent a one time password to the user (two factor), the access must not be allowed until it's verified
+	//(see OtpVerifier, the token is in Decision.Response.TwoFactor())
+	VerdictTwoFactorRequired
+)
+
+//Implementation of the fmt.Stringer interface
+func (v Verdict) String() string {
+	switch v {
+	case VerdictAllow:
+		return "allow"
+	case VerdictDeny:
+		return "deny"
+	case VerdictTwoFactorRequired:
+		return "two_factor_required"
+	}
+	return "unknown"
+}
+
+//Reasons for the verdicts of a Guard check
+const (
+	REASON_STATUS_ON           = "status_on"
+	REASON_STATUS_OFF          = "status_off"
+	REASON_TWO_FACTOR_REQUIRED = "two_factor_required"
+	REASON_LATCH_ERROR         = "latch_error"
+	REASON_FAIL_OPEN           = "fail_open"
+	REASON_FAIL_CLOSED         = "fail_closed"
+	REASON_LAST_KNOWN_GOOD     = "last_known_good"
+	REASON_UNEXPECTED_VALUE    = "unexpected_status"
+)
+
+//What to do when Latch is unavailable (see IsUnavailableError())
+type FailurePolicy int
+
+const (
+	//Deny the access
+	FailClosed FailurePolicy = iota
+	//Allow the access
+	FailOpen
+)
+
+//Result of a Guard check
+type Decision struct {
+	Verdict Verdict
+	Reason  string
+	//Status response the verdict is based on (the last known one when Reason is REASON_LAST_KNOWN_GOOD)
+	Response *LatchStatusResponse
+	//Error returned by Latch (if any)
+	Err error
+	//When the status the verdict is based on was received
+	CheckedAt time.Time
+}
+
+//Returns true if the access is allowed
+func (d Decision) Allowed() bool {
+	return d.Verdict == VerdictAllow
+}
+
+//Turns the status of an account or operation into an explicit allow/deny decision, applying a policy when Latch is unavailable
+type Guard struct {
+	Latch *Latch
+	//Policy applied when Latch is unavailable and there is no usable last known status
+	Policy FailurePolicy
+	//If greater than zero, the last known status of the account/operation is used when Latch is unavailable, as long as it is not older than this
+	MaxStaleness time.Duration
+	//Options of the status requests, applied after the default status options of the client (see Latch.SetDefaultStatusOptions())
+	Options []StatusOption
+
+	mu        sync.Mutex
+	lastKnown map[StatusCacheKey]Decision
+}
+
+//Returns a new guard for the Latch client provided that applies the policy provided when Latch is unavailable
+func NewGuard(latch *Latch, policy FailurePolicy) *Guard {
+	return &Guard{Latch: latch, Policy: policy}
+}
+
+//Checks the status of an account
+func (g *Guard) Check(ctx context.Context, accountId string) Decision {
+	return g.check(ctx, accountId, WithOperation(""))
+}
+
+//Checks the status of an operation of an account
+func (g *Guard) CheckOperation(ctx context.Context, accountId string, operationId string) Decision {
+	return g.check(ctx, accountId, WithOperation(operationId))
+}
+
+func (g *Guard) check(ctx context.Context, accountId string, options ...StatusOption) Decision {
+	options = append(append([]StatusOption{}, g.Options...), options...)
+	response, err := g.Latch.StatusWithOptions(ctx, accountId, options...)
+	return g.decide(g.Latch.statusOptions(options...).cacheKey(accountId, g.Latch.XHeaders), response, err)
+}
+
+//Builds the decision for a status response (or error)
+func (g *Guard) decide(key StatusCacheKey, response *LatchStatusResponse, err error) Decision {
+	now := time.Now()
+
+	switch {
+	case err == nil:
+		decision := Decision{Verdict: VerdictUnknown, Reason: REASON_UNEXPECTED_VALUE, Response: response, CheckedAt: now}
+		switch response.Status() {
+		case LATCH_STATUS_ON:
+			decision.Verdict, decision.Reason = VerdictAllow, REASON_STATUS_ON
+			if response.TwoFactor().Token != "" {
+				decision.Verdict, decision.Reason = VerdictTwoFactorRequired, REASON_TWO_FACTOR_REQUIRED
+			}
+		case LATCH_STATUS_OFF:
+			decision.Verdict, decision.Reason = VerdictDeny, REASON_STATUS_OFF
+		}
+		g.remember(key, decision)
+		return decision
+	case !IsUnavailableError(err):
+		//Latch answered with an error or the request couldn't be made, so there's no status to decide on
+		return Decision{Verdict: VerdictUnknown, Reason: REASON_LATCH_ERROR, Err: err, CheckedAt: now}
+	}
+
+	if g.MaxStaleness > 0 {
+		if last, ok := g.recall(key); ok && now.Sub(last.CheckedAt) <= g.MaxStaleness {
+			last.Reason, last.Err = REASON_LAST_KNOWN_GOOD, err
+			return last
+		}
+	}
+	if g.Policy == FailOpen {
+		return Decision{Verdict: VerdictAllow, Reason: REASON_FAIL_OPEN, Err: err, CheckedAt: now}
+	}
+	return Decision{Verdict: VerdictDeny, Reason: REASON_FAIL_CLOSED, Err: err, CheckedAt: now}
+}
+
+//Checks if an error means that Latch couldn't be reached or couldn't answer: network errors, timeouts
+//and HTTP errors caused by the server or by too many requests
+//Errors returned by the Latch API, canceled requests and errors of the client itself (invalid headers, actions not supported,
+//signing errors, the client rate limit...) are not
+func IsUnavailableError(err error) bool {
+	var http_error *LatchHttpError
+	var latch_error *LatchError
+	var url_error *url.Error
+	var net_error net.Error
+
+	switch {
+	case err == nil, errors.As(err, &latch_error), errors.Is(err, ErrSigningFailed), errors.Is(err, ErrRequestCanceled):
+		return false
+	case errors.Is(err, ErrRequestTimeout):
+		return true
+	case errors.As(err, &http_error):
+		return http_error.IsServerError() || http_error.StatusCode == http.StatusTooManyRequests
+	}
+
+	return errors.As(err, &url_error) || errors.As(err, &net_error) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+//Stores the last known decision for a key (only when last known statuses are enabled)
+func (g *Guard) remember(key StatusCacheKey, decision Decision) {
+	//One time passwords can't be reused, so two factor decisions are not kept either
+	if g.MaxStaleness <= 0 || decision.Verdict == VerdictUnknown || decision.Verdict == VerdictTwoFactorRequired {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.lastKnown == nil {
+		g.lastKnown = make(map[StatusCacheKey]Decision)
+	}
+	g.lastKnown[key] = decision
+}
+
+func (g *Guard) recall(key StatusCacheKey) (Decision, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	decision, ok := g.lastKnown[key]
+	return decision, ok
+}
@@ -0,0 +1,166 @@
+package golatch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/millenc/golatch"
+	"github.com/millenc/golatch/golatchtest"
+)
+
+//Returns a guard of MyAppID for the fake Latch API, that answers with a 503 error while unavailable is set
+func newTestGuard(server *golatchtest.Server, policy golatch.FailurePolicy, unavailable *atomic.Bool) (*golatch.Guard, *httptest.Server) {
+	front := newFrontServer(server, func(w http.ResponseWriter, r *http.Request) bool {
+		if unavailable.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return false
+		}
+		return true
+	})
+	return golatch.NewGuard(newFrontLatch(server, front), policy), front
+}
+
+func TestGuardCheck(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	server.PairAccount("MyAppID", "MyLockedAccountID")
+	server.SetStatus("MyAppID", "MyLockedAccountID", golatch.LATCH_STATUS_OFF)
+	server.AddOperation("MyAppID", "MyAppID", "MyTwoFactorOperationID", "My Two Factor Operation").TwoFactor = golatch.MANDATORY
+
+	tests := []struct {
+		account     string
+		operation   string
+		unavailable bool
+		policy      golatch.FailurePolicy
+		verdict     golatch.Verdict
+		reason      string
+	}{
+		{"MyAccountID", "", false, golatch.FailClosed, golatch.VerdictAllow, golatch.REASON_STATUS_ON},
+		{"MyLockedAccountID", "", false, golatch.FailOpen, golatch.VerdictDeny, golatch.REASON_STATUS_OFF},
+		{"MyAccountID", "MyTwoFactorOperationID", false, golatch.FailOpen, golatch.VerdictTwoFactorRequired, golatch.REASON_TWO_FACTOR_REQUIRED},
+		{"MyUnpairedAccountID", "", false, golatch.FailOpen, golatch.VerdictUnknown, golatch.REASON_LATCH_ERROR},
+		{"MyAccountID", "", true, golatch.FailOpen, golatch.VerdictAllow, golatch.REASON_FAIL_OPEN},
+		{"MyAccountID", "", true, golatch.FailClosed, golatch.VerdictDeny, golatch.REASON_FAIL_CLOSED},
+	}
+
+	for _, test := range tests {
+		unavailable := &atomic.Bool{}
+		unavailable.Store(test.unavailable)
+		guard, front := newTestGuard(server, test.policy, unavailable)
+
+		decision := guard.Check(context.Background(), test.account)
+		if test.operation != "" {
+			decision = guard.CheckOperation(context.Background(), test.account, test.operation)
+		}
+		if decision.Verdict != test.verdict || decision.Reason != test.reason {
+			t.Errorf("Guard.Check() failed: expected %v (%s) for %s %s, got %v (%s)", test.verdict, test.reason, test.account, test.operation, decision.Verdict, decision.Reason)
+		}
+		front.Close()
+	}
+}
+
+func TestGuardClientErrors(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	guard, front := newTestGuard(server, golatch.FailOpen, &atomic.Bool{})
+	defer front.Close()
+
+	//Errors that don't mean Latch is unavailable never apply the failure policy
+	guard.Options = []golatch.StatusOption{golatch.WithStatusXHeaders(map[string]string{"X-Invalid": "value"})}
+	if decision := guard.Check(context.Background(), "MyAccountID"); decision.Verdict != golatch.VerdictUnknown || decision.Reason != golatch.REASON_LATCH_ERROR || !errors.Is(decision.Err, golatch.ErrInvalidXHeader) {
+		t.Errorf("Guard.Check() failed: expected unknown verdict for an invalid header, got %v (%s, %v)", decision.Verdict, decision.Reason, decision.Err)
+	}
+
+	guard.Options = nil
+	guard.Latch.SetRateLimit(&golatch.RateLimitPolicy{Global: golatch.NewRateLimiter(1, 1), FailFast: true})
+	guard.Check(context.Background(), "MyAccountID")
+	if decision := guard.Check(context.Background(), "MyAccountID"); decision.Verdict != golatch.VerdictUnknown || !errors.Is(decision.Err, golatch.ErrRateLimitExceeded) {
+		t.Errorf("Guard.Check() failed: expected unknown verdict when the rate limit is exceeded, got %v (%s, %v)", decision.Verdict, decision.Reason, decision.Err)
+	}
+}
+
+func TestGuardStatusOptions(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+
+	//The guard applies the default options of the client and then its own options
+	guard := golatch.NewGuard(server.Latch("MyAppID"), golatch.FailClosed)
+	guard.Latch.SetDefaultStatusOptions(golatch.WithNoOtp(true), golatch.WithStatusXHeaders(map[string]string{"X-11Paths-Tenant": "MyTenant"}))
+	guard.Options = []golatch.StatusOption{golatch.WithSilent(true)}
+
+	if decision := guard.CheckOperation(context.Background(), "MyAccountID", "MyOperationID"); !decision.Allowed() {
+		t.Errorf("Guard.CheckOperation() failed: expected allow, got %v (%s, %v)", decision.Verdict, decision.Reason, decision.Err)
+	}
+	call, _ := server.LastCall()
+	if call.Path != "/api/1.0/status/MyAccountID/op/MyOperationID/nootp/silent" || http.Header(call.Header).Get("X-11Paths-Tenant") != "MyTenant" {
+		t.Errorf("Guard.CheckOperation() failed: expected the default and guard options, got path %q and headers %v", call.Path, call.Header)
+	}
+
+	guard.Check(context.Background(), "MyAccountID")
+	if call, _ := server.LastCall(); call.Path != "/api/1.0/status/MyAccountID/nootp/silent" {
+		t.Errorf("Guard.Check() failed: unexpected path %q", call.Path)
+	}
+}
+
+func TestIsUnavailableError(t *testing.T) {
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+	_, transport_err := http.Get(closed.URL)
+
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{transport_err, true},
+		{fmt.Errorf("%w: %w", golatch.ErrRequestTimeout, context.DeadlineExceeded), true},
+		{fmt.Errorf("%w: %w", golatch.ErrRequestCanceled, context.Canceled), false},
+		{&golatch.LatchHttpError{StatusCode: http.StatusBadGateway}, true},
+		{&golatch.LatchHttpError{StatusCode: http.StatusTooManyRequests}, true},
+		{&golatch.LatchHttpError{StatusCode: http.StatusNotFound}, false},
+		{golatch.ErrAccountNotPaired, false},
+		{fmt.Errorf("%w: %w", golatch.ErrSigningFailed, transport_err), false},
+		{golatch.ErrInvalidXHeader, false},
+		{golatch.ErrActionNotSupported, false},
+		{golatch.ErrRateLimitExceeded, false},
+	}
+
+	for _, test := range tests {
+		if got := golatch.IsUnavailableError(test.err); got != test.expected {
+			t.Errorf("IsUnavailableError() failed: expected %v for %v, got %v", test.expected, test.err, got)
+		}
+	}
+}
+
+func TestGuardLastKnownGood(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	unavailable := &atomic.Bool{}
+	guard, front := newTestGuard(server, golatch.FailClosed, unavailable)
+	defer front.Close()
+	guard.MaxStaleness = 50 * time.Millisecond
+
+	if decision := guard.CheckOperation(context.Background(), "MyAccountID", "MyOperationID"); !decision.Allowed() {
+		t.Fatalf("Guard.CheckOperation() failed: expected allow, got %v (%s)", decision.Verdict, decision.Reason)
+	}
+
+	unavailable.Store(true)
+	decision := guard.CheckOperation(context.Background(), "MyAccountID", "MyOperationID")
+	if decision.Verdict != golatch.VerdictAllow || decision.Reason != golatch.REASON_LAST_KNOWN_GOOD || decision.Err == nil || decision.Response == nil {
+		t.Errorf("Guard.CheckOperation() failed: expected last known allow verdict, got %v (%s)", decision.Verdict, decision.Reason)
+	}
+	if decision := guard.CheckOperation(context.Background(), "MyAccountID", "OtherOperationID"); decision.Reason != golatch.REASON_FAIL_CLOSED {
+		t.Errorf("Guard.CheckOperation() failed: expected fail closed for an unknown operation, got %v (%s)", decision.Verdict, decision.Reason)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if decision := guard.CheckOperation(context.Background(), "MyAccountID", "MyOperationID"); decision.Verdict != golatch.VerdictDeny || decision.Reason != golatch.REASON_FAIL_CLOSED {
+		t.Errorf("Guard.CheckOperation() failed: expected fail closed once the last known status is too old, got %v (%s)", decision.Verdict, decision.Reason)
+	}
+}
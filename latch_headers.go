@@ -0,0 +1,103 @@
+package golatch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+//Error matched (using errors.Is()) by the errors returned when an extra X-11Paths header is not valid
+var ErrInvalidXHeader = errors.New("invalid X-11Paths header")
+
+//Sets extra X-11Paths headers that will be sent (and signed) in all the requests of the client
+//Header names must start with API_X_11PATHS_HEADER_PREFIX (X-11Paths-) and X-11Paths-Date is reserved. Invalid headers are
+//reported by the requests (see ValidateXHeaders()). The headers set before are replaced (nil removes them)
+func (l *LatchAPI) SetXHeaders(headers map[string]string) {
+	l.XHeaders = mergeXHeaders(nil, headers)
+}
+
+//Returns a copy of the client that sends the extra X-11Paths headers provided (see SetXHeaders()) along with the ones of the client,
+//so the headers of a single call can be set explicitly:
+//
+//	latch.WithXHeaders(map[string]string{"X-11Paths-Session": sessionId}).Lock(accountId)
+//
+//The copy shares the status cache, the rate limit and the rest of the settings of the client
+func (l *Latch) WithXHeaders(headers map[string]string) *Latch {
+	return &Latch{
+		AppID:                l.AppID,
+		SecretKey:            l.SecretKey,
+		StatusCache:          l.StatusCache,
+		DefaultStatusOptions: l.DefaultStatusOptions,
+		LatchAPI:             l.LatchAPI.withXHeaders(headers),
+	}
+}
+
+//Same as Latch.WithXHeaders() for the user API
+func (l *LatchUser) WithXHeaders(headers map[string]string) *LatchUser {
+	return &LatchUser{
+		UserID:    l.UserID,
+		SecretKey: l.SecretKey,
+		LatchAPI:  l.LatchAPI.withXHeaders(headers),
+	}
+}
+
+//Returns a copy of the settings of the client with the extra headers provided added to the ones of the client
+//(the fields are copied one by one so the clock skew, that may be updated concurrently, is read atomically)
+func (l *LatchAPI) withXHeaders(headers map[string]string) LatchAPI {
+	api := LatchAPI{
+		APIURL:            l.APIURL,
+		APIPath:           l.APIPath,
+		APIVersion:        l.APIVersion,
+		Proxy:             l.Proxy,
+		HttpClient:        l.HttpClient,
+		Transport:         l.Transport,
+		RetryPolicy:       l.RetryPolicy,
+		RateLimit:         l.RateLimit,
+		Clock:             l.Clock,
+		CorrectClockSkew:  l.CorrectClockSkew,
+		Logger:            l.Logger,
+		Observer:          l.Observer,
+		Signer:            l.Signer,
+		XHeaders:          mergeXHeaders(l.XHeaders, headers),
+		OnRequestStart:    l.OnRequestStart,
+		OnResponseReceive: l.OnResponseReceive,
+	}
+	api.clockSkew = int64(l.ClockSkew())
+	return api
+}
+
+//Merges two sets of extra headers, with canonical names (the headers provided override the current ones)
+func mergeXHeaders(current map[string]string, headers map[string]string) map[string]string {
+	if len(current) == 0 && len(headers) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]string)
+	for name, value := range current {
+		merged[name] = value
+	}
+	for name, value := range headers {
+		merged[http.CanonicalHeaderKey(name)] = value
+	}
+	return merged
+}
+
+//Checks that the names of the headers start with API_X_11PATHS_HEADER_PREFIX (X-11Paths-), are valid HTTP header names and are not reserved (X-11Paths-Date)
+func ValidateXHeaders(headers map[string]string) error {
+	for name := range headers {
+		switch {
+		case len(name) <= len(API_X_11PATHS_HEADER_PREFIX) || !strings.EqualFold(name[:len(API_X_11PATHS_HEADER_PREFIX)], API_X_11PATHS_HEADER_PREFIX):
+			return fmt.Errorf("%w: %q must start with %s", ErrInvalidXHeader, name, API_X_11PATHS_HEADER_PREFIX)
+		case strings.EqualFold(name, API_DATE_HEADER_NAME):
+			return fmt.Errorf("%w: %s is set by the client", ErrInvalidXHeader, API_DATE_HEADER_NAME)
+		case strings.IndexFunc(name, func(r rune) bool { return !isHeaderNameChar(r) }) >= 0:
+			return fmt.Errorf("%w: %q is not a valid header name", ErrInvalidXHeader, name)
+		}
+	}
+	return nil
+}
+
+func isHeaderNameChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_'
+}
@@ -0,0 +1,79 @@
+package golatch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidateXHeaders(t *testing.T) {
+	valid := map[string]string{"X-11Paths-Client-Ip": "127.0.0.1", "x-11paths-session": "MySession"}
+	if err := ValidateXHeaders(valid); err != nil {
+		t.Errorf("ValidateXHeaders() failed: unexpected error %v", err)
+	}
+
+	for _, name := range []string{"X-Client-Ip", "X-11Paths-", "X-11Paths-Date", "X-11Paths-Client Ip", "X-11Paths-Session:"} {
+		if err := ValidateXHeaders(map[string]string{name: "value"}); !errors.Is(err, ErrInvalidXHeader) {
+			t.Errorf("ValidateXHeaders() failed: expected ErrInvalidXHeader for %q, got %v", name, err)
+		}
+	}
+}
+
+func TestWithXHeaders(t *testing.T) {
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetXHeaders(map[string]string{"x-11paths-a": "A", "X-11Paths-B": "B"})
+	latch.SetStatusCache(NewStatusCache(time.Minute))
+
+	scoped := latch.WithXHeaders(map[string]string{"X-11PATHS-B": "Other B", "X-11Paths-C": "C"})
+	if len(scoped.XHeaders) != 3 || scoped.XHeaders["X-11paths-A"] != "A" || scoped.XHeaders["X-11paths-B"] != "Other B" || scoped.XHeaders["X-11paths-C"] != "C" {
+		t.Errorf("WithXHeaders() failed: expected headers to be merged, got %v", scoped.XHeaders)
+	}
+	if len(latch.XHeaders) != 2 || latch.XHeaders["X-11paths-B"] != "B" {
+		t.Errorf("WithXHeaders() failed: expected the headers of the client to be left as they are, got %v", latch.XHeaders)
+	}
+	if scoped.AppID != latch.AppID || scoped.StatusCache != latch.StatusCache {
+		t.Errorf("WithXHeaders() failed: expected the copy to keep the settings of the client")
+	}
+
+	latch.SetXHeaders(nil)
+	if latch.XHeaders != nil {
+		t.Errorf("SetXHeaders() failed: expected nil to remove the headers, got %v", latch.XHeaders)
+	}
+}
+
+func TestXHeadersAreSigned(t *testing.T) {
+	var got_header http.Header
+	verifier := NewLatchVerifier(testSecretLookup, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := verifier.Verify(r); err != nil {
+			w.Write([]byte(`{"error":{"code":102,"message":"Invalid application signature"}}`))
+			return
+		}
+		got_header = r.Header
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+
+	if err := latch.WithXHeaders(map[string]string{"X-11Paths-Session": "MySession\nId"}).Lock("MyAccountID"); err != nil {
+		t.Fatalf("Lock() failed: unexpected error %v", err)
+	}
+	if got_header.Get("X-11Paths-Session") != "MySession Id" {
+		t.Errorf("Lock() failed: expected X-11Paths header to be sent, got %v", got_header)
+	}
+
+	//The headers of a call are not sent with the following ones
+	latch.Unlock("MyAccountID")
+	if got_header.Get("X-11Paths-Session") != "" {
+		t.Errorf("Unlock() failed: expected no X-11Paths header, got %v", got_header)
+	}
+
+	got_header = nil
+	if _, err := latch.WithXHeaders(map[string]string{"X-Session": "MySession"}).Status("MyAccountID", false, false); !errors.Is(err, ErrInvalidXHeader) || got_header != nil {
+		t.Errorf("Status() failed: expected ErrInvalidXHeader and no request, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package golatch
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+//Value logged instead of secrets (secret keys, Authorization headers, two factor tokens and pairing tokens)
+const REDACTED = "[REDACTED]"
+
+//Sets the logger used to log the requests sent to the API (nil disables logging)
+//Successful requests are logged at debug level, errors returned by Latch at warn level and any other error at error level
+func (l *LatchAPI) SetLogger(logger *slog.Logger) {
+	l.Logger = logger
+}
+
+//Logs an attempt of a request
+func (l *LatchAPI) logAttempt(ctx context.Context, request *LatchRequest, attempt int, latency time.Duration, err error) {
+	if l.Logger == nil {
+		return
+	}
+
+	accountId, operationId := request.GetAccountAndOperation()
+	attrs := []slog.Attr{
+		slog.String("action", request.Action),
+		slog.String("method", request.HttpMethod),
+		slog.String("path", request.GetRedactedPath()),
+		slog.Int("attempt", attempt),
+		slog.Duration("latency", latency),
+	}
+	if accountId != "" {
+		attrs = append(attrs, slog.String("account_id", accountId))
+	}
+	if operationId != "" {
+		attrs = append(attrs, slog.String("operation_id", operationId))
+	}
+
+	if code := statusCode(err); code != 0 {
+		attrs = append(attrs, slog.Int("status_code", code))
+	}
+
+	level, message := slog.LevelDebug, "Latch request succeeded"
+	var latch_error *LatchError
+	switch {
+	case err == nil:
+	case errors.As(err, &latch_error):
+		level, message = slog.LevelWarn, "Latch request returned an error"
+		attrs = append(attrs, slog.Int("latch_error_code", int(latch_error.Code)), slog.String("error", request.GetRedactedError(err)))
+	default:
+		level, message = slog.LevelError, "Latch request failed"
+		attrs = append(attrs, slog.String("error", request.GetRedactedError(err)))
+	}
+
+	l.Logger.LogAttrs(ctx, level, message, attrs...)
+}
+
+//Gets the account and operation IDs of the request from its path (empty when not present)
+func (l *LatchRequest) GetAccountAndOperation() (accountId string, operationId string) {
+	segments := l.actionSegments()
+	if len(segments) < 2 {
+		return "", ""
+	}
+
+	switch l.Action {
+	case API_OPERATION_ACTION:
+		return "", segments[1]
+	case API_CHECK_STATUS_ACTION, API_UNPAIR_ACTION, API_LOCK_ACTION, API_UNLOCK_ACTION, API_HISTORY_ACTION:
+		accountId = segments[1]
+		if len(segments) > 3 && segments[2] == "op" {
+			operationId = segments[3]
+		}
+	}
+
+	return accountId, operationId
+}
+
+//Gets the path of the request with the pairing token (if any) redacted
+func (l *LatchRequest) GetRedactedPath() string {
+	if l.URL == nil {
+		return ""
+	}
+
+	if l.Action == API_PAIR_ACTION {
+		if i := strings.LastIndex(l.URL.Path, "/"+API_PAIR_ACTION+"/"); i >= 0 {
+			return l.URL.Path[:i] + "/" + API_PAIR_ACTION + "/" + REDACTED
+		}
+	}
+	return l.URL.Path
+}
+
+//Gets the message of an error of the request with the pairing token (if any) redacted
+//Errors of the HTTP client (*url.Error) include the URL of the request, and with it the token
+func (l *LatchRequest) GetRedactedError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	message := err.Error()
+	if segments := l.actionSegments(); l.Action == API_PAIR_ACTION && len(segments) > 1 && segments[1] != "" {
+		message = strings.ReplaceAll(message, "/"+API_PAIR_ACTION+"/"+segments[1], "/"+API_PAIR_ACTION+"/"+REDACTED)
+	}
+	return message
+}
+
+//Gets the segments of the path starting with the action (nil if the action is not known)
+func (l *LatchRequest) actionSegments() []string {
+	if l.Action == "" || l.URL == nil {
+		return nil
+	}
+
+	segments := strings.Split(l.URL.Path, "/")
+	for i := range segments {
+		if segments[i] == l.Action {
+			return segments[i:]
+		}
+	}
+	return nil
+}
+
+//Implementation of the slog.LogValuer interface: the secret key and signature are never logged
+func (l *LatchRequest) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("action", l.Action),
+		slog.String("app_id", l.AppID),
+		slog.String("secret_key", REDACTED),
+		slog.String("method", l.HttpMethod),
+		slog.String("path", l.GetRedactedPath()),
+		slog.String("date", l.GetFormattedDate()),
+		slog.String(strings.ToLower(API_AUTHORIZATION_HEADER_NAME), REDACTED),
+	)
+}
+
+//Implementation of the slog.LogValuer interface: the token is never logged
+func (t LatchTwoFactor) LogValue() slog.Value {
+	token := ""
+	if t.Token != "" {
+		token = REDACTED
+	}
+	return slog.GroupValue(slog.String("token", token), slog.Int64("generated", t.Generated))
+}
@@ -0,0 +1,117 @@
+package golatch_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/millenc/golatch"
+)
+
+func setTestLogger(latch *golatch.Latch, buffer *bytes.Buffer) {
+	latch.SetLogger(slog.New(slog.NewJSONHandler(buffer, &slog.HandlerOptions{Level: slog.LevelDebug})))
+}
+
+//Decodes the JSON log lines written to a buffer
+func logEntries(t *testing.T, buffer *bytes.Buffer) (entries []map[string]interface{}) {
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLogger(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	server.AddOperation("MyAppID", "MyAppID", "MyTwoFactorOperationID", "My Two Factor Operation").TwoFactor = golatch.MANDATORY
+	//Locks fail with a 500 error
+	front := newFrontServer(server, func(w http.ResponseWriter, r *http.Request) bool {
+		if strings.Contains(r.URL.Path, "/lock/") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return false
+		}
+		return true
+	})
+	defer front.Close()
+
+	var buffer bytes.Buffer
+	latch := newFrontLatch(server, front)
+	setTestLogger(latch, &buffer)
+
+	status, err := latch.OperationStatus("MyAccountID", "MyTwoFactorOperationID", false, false)
+	if err != nil || status.TwoFactor().Token == "" {
+		t.Fatalf("OperationStatus() failed: expected two factor token, got %v (error %v)", status, err)
+	}
+	latch.Pair("MyPairingToken")
+	latch.Lock("MyAccountID")
+
+	entries := logEntries(t, &buffer)
+	if len(entries) != 3 {
+		t.Fatalf("SetLogger() failed: expected 3 log entries, got %d", len(entries))
+	}
+
+	expected := []map[string]interface{}{
+		{"level": "DEBUG", "action": "status", "account_id": "MyAccountID", "operation_id": "MyTwoFactorOperationID", "status_code": 200.0},
+		{"level": "WARN", "action": "pair", "path": "/api/1.0/pair/" + golatch.REDACTED, "latch_error_code": 206.0},
+		{"level": "ERROR", "action": "lock", "account_id": "MyAccountID", "status_code": 500.0},
+	}
+	for i, fields := range expected {
+		for key, value := range fields {
+			if entries[i][key] != value {
+				t.Errorf("SetLogger() failed: expected %s=%v in entry %d, got %v", key, value, i, entries[i][key])
+			}
+		}
+	}
+
+	for _, secret := range []string{"MySecretKey", status.TwoFactor().Token, "MyPairingToken", "11PATHS"} {
+		if strings.Contains(buffer.String(), secret) {
+			t.Errorf("SetLogger() failed: %q should not be logged: %s", secret, buffer.String())
+		}
+	}
+}
+
+func TestLogValueRedaction(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, nil))
+
+	request := golatch.NewLatchRequest("MyAppID", "MySecretKey", golatch.HTTP_METHOD_GET, golatch.GetLatchURL("status/MyAccountID"), nil, nil, time.Now())
+	logger.Info("request", "request", request, "two_factor", golatch.LatchTwoFactor{Token: "S3CR3T", Generated: 1})
+
+	if strings.Contains(buffer.String(), "MySecretKey") || strings.Contains(buffer.String(), "S3CR3T") || strings.Contains(buffer.String(), request.GetAuthorizationHeader()) {
+		t.Errorf("LogValue() failed: secrets should be redacted, got %s", buffer.String())
+	}
+	if !strings.Contains(buffer.String(), `"app_id":"MyAppID"`) {
+		t.Errorf("LogValue() failed: expected app ID to be logged, got %s", buffer.String())
+	}
+}
+
+func TestLoggerRedactsTransportErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	var buffer bytes.Buffer
+	latch := golatch.NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+	setTestLogger(latch, &buffer)
+
+	if _, err := latch.Pair("MyPairingToken"); err == nil || !strings.Contains(err.Error(), "MyPairingToken") {
+		t.Fatalf("Pair() failed: expected a transport error with the URL, got %v", err)
+	}
+
+	entries := logEntries(t, &buffer)
+	if len(entries) != 1 || entries[0]["level"] != "ERROR" || !strings.Contains(entries[0]["error"].(string), "/pair/"+golatch.REDACTED) {
+		t.Errorf("SetLogger() failed: expected the error to be logged with the token redacted, got %s", buffer.String())
+	}
+	if strings.Contains(buffer.String(), "MyPairingToken") {
+		t.Errorf("SetLogger() failed: the pairing token should not be logged: %s", buffer.String())
+	}
+}
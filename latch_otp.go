@@ -0,0 +1,237 @@
+package golatch
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Default validity of the one time passwords (counted from the time Latch generated them) and default max number of verification attempts
+const (
+	DEFAULT_OTP_VALIDITY     = 5 * time.Minute
+	DEFAULT_OTP_MAX_ATTEMPTS = 3
+)
+
+//Errors returned when creating and verifying one time password challenges
+var (
+	ErrNoTwoFactor          = errors.New("Latch status response without two factor token")
+	ErrOtpChallengeNotFound = errors.New("one time password challenge not found")
+	ErrOtpChallengeExpired  = errors.New("one time password challenge expired")
+	ErrInvalidOtp           = errors.New("invalid one time password")
+	ErrTooManyOtpAttempts   = errors.New("too many one time password attempts")
+)
+
+//One time password sent by Latch to the user, waiting to be verified
+type OtpChallenge struct {
+	ID        string
+	Token     string
+	Generated time.Time
+	Expires   time.Time
+	//Number of verification attempts made so far
+	Attempts int
+}
+
+//Storage of the pending challenges. Implementations must be safe for concurrent use
+//and may be backed by a shared store (Redis, a database...) when several instances of an application verify the same challenges
+type OtpChallengeStore interface {
+	//Saves a new challenge
+	Save(ctx context.Context, challenge *OtpChallenge) error
+	//Increments the attempts of a challenge and returns it (with the attempts already incremented) in a single atomic operation
+	//Returns ErrOtpChallengeNotFound if there's no challenge with that ID
+	Attempt(ctx context.Context, id string) (*OtpChallenge, error)
+	//Deletes a challenge (it's not an error if it doesn't exist)
+	Delete(ctx context.Context, id string) error
+}
+
+//Implemented by the challenge stores that have to remove the expired challenges themselves (stores that expire keys on their own don't need it)
+//OtpVerifier calls it before saving a new challenge, with the current time of its clock
+type OtpChallengeSweeper interface {
+	//Deletes the challenges that expire before the time provided
+	Sweep(ctx context.Context, now time.Time) error
+}
+
+//Creates challenges with the two factor tokens of status responses and verifies the one time passwords entered by the users
+type OtpVerifier struct {
+	Store OtpChallengeStore
+	//Time the challenges are valid for, counted from the time Latch generated the token (DEFAULT_OTP_VALIDITY if 0)
+	Validity time.Duration
+	//Max number of verification attempts of a challenge (DEFAULT_OTP_MAX_ATTEMPTS if 0)
+	MaxAttempts int
+	//Source of the current time (nil means the system clock)
+	Clock Clock
+}
+
+//Returns a new verifier that keeps the challenges in the store provided (nil means a new in-memory store)
+func NewOtpVerifier(store OtpChallengeStore) *OtpVerifier {
+	if store == nil {
+		store = NewMemoryOtpChallengeStore()
+	}
+	return &OtpVerifier{Store: store}
+}
+
+//Creates a challenge for the two factor token of a status response (see Latch.Status()) and saves it in the store
+//The ID of the challenge must be kept (for example in the user's session) to verify the one time password later
+//Returns ErrNoTwoFactor if the response doesn't include a token and ErrOtpChallengeExpired if it was generated too long ago
+func (v *OtpVerifier) NewChallenge(ctx context.Context, response *LatchStatusResponse) (*OtpChallenge, error) {
+	twoFactor := response.TwoFactor()
+	if twoFactor.Token == "" {
+		return nil, ErrNoTwoFactor
+	}
+
+	now := v.now()
+	generated := now
+	if twoFactor.Generated > 0 {
+		generated = time.Unix(0, twoFactor.Generated*int64(time.Millisecond))
+	}
+	id, err := newOtpChallengeID()
+	if err != nil {
+		return nil, err
+	}
+	challenge := &OtpChallenge{
+		ID:        id,
+		Token:     twoFactor.Token,
+		Generated: generated,
+		Expires:   generated.Add(v.validity()),
+	}
+	if !now.Before(challenge.Expires) {
+		return nil, ErrOtpChallengeExpired
+	}
+
+	if sweeper, ok := v.Store.(OtpChallengeSweeper); ok {
+		if err := sweeper.Sweep(ctx, now); err != nil {
+			return nil, err
+		}
+	}
+	if err := v.Store.Save(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+//Verifies the one time password entered by a user for a challenge
+//Returns nil if it's valid, ErrInvalidOtp if it's not, and ErrOtpChallengeNotFound, ErrOtpChallengeExpired or ErrTooManyOtpAttempts
+//if the challenge can't be used anymore. Verified and expired challenges are deleted from the store
+//Leading and trailing spaces are ignored and the comparison is case insensitive
+func (v *OtpVerifier) Verify(ctx context.Context, id string, otp string) error {
+	challenge, err := v.Store.Attempt(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !v.now().Before(challenge.Expires) {
+		v.Store.Delete(ctx, id)
+		return ErrOtpChallengeExpired
+	}
+	if challenge.Attempts > v.maxAttempts() {
+		return ErrTooManyOtpAttempts
+	}
+	if subtle.ConstantTimeCompare([]byte(normalizeOtp(otp)), []byte(normalizeOtp(challenge.Token))) != 1 {
+		return ErrInvalidOtp
+	}
+	return v.Store.Delete(ctx, id)
+}
+
+func (v *OtpVerifier) validity() time.Duration {
+	if v.Validity <= 0 {
+		return DEFAULT_OTP_VALIDITY
+	}
+	return v.Validity
+}
+
+func (v *OtpVerifier) maxAttempts() int {
+	if v.MaxAttempts <= 0 {
+		return DEFAULT_OTP_MAX_ATTEMPTS
+	}
+	return v.MaxAttempts
+}
+
+func (v *OtpVerifier) now() time.Time {
+	if v.Clock == nil {
+		return SystemClock.Now()
+	}
+	return v.Clock.Now()
+}
+
+func normalizeOtp(otp string) string {
+	return strings.ToUpper(strings.TrimSpace(otp))
+}
+
+//Generates a random challenge ID
+func newOtpChallengeID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate the one time password challenge ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+//In-memory challenge store. Expired challenges are removed by the verifier when new ones are created (see OtpChallengeSweeper)
+type MemoryOtpChallengeStore struct {
+	mu         sync.Mutex
+	challenges map[string]*OtpChallenge
+}
+
+//Returns a new in-memory challenge store
+func NewMemoryOtpChallengeStore() *MemoryOtpChallengeStore {
+	return &MemoryOtpChallengeStore{challenges: make(map[string]*OtpChallenge)}
+}
+
+//Implementation of the OtpChallengeStore interface
+func (s *MemoryOtpChallengeStore) Save(ctx context.Context, challenge *OtpChallenge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *challenge
+	s.challenges[challenge.ID] = &saved
+	return nil
+}
+
+//Implementation of the OtpChallengeStore interface
+func (s *MemoryOtpChallengeStore) Attempt(ctx context.Context, id string) (*OtpChallenge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	challenge, ok := s.challenges[id]
+	if !ok {
+		return nil, ErrOtpChallengeNotFound
+	}
+	challenge.Attempts++
+	attempted := *challenge
+	return &attempted, nil
+}
+
+//Implementation of the OtpChallengeStore interface
+func (s *MemoryOtpChallengeStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.challenges, id)
+	return nil
+}
+
+//Implementation of the OtpChallengeSweeper interface
+func (s *MemoryOtpChallengeStore) Sweep(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, challenge := range s.challenges {
+		if !now.Before(challenge.Expires) {
+			delete(s.challenges, id)
+		}
+	}
+	return nil
+}
+
+//Gets the number of challenges in the store
+func (s *MemoryOtpChallengeStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.challenges)
+}
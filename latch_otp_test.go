@@ -0,0 +1,99 @@
+package golatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+//Builds a status response with a two factor token generated at the time provided
+func newTwoFactorResponse(t *testing.T, token string, generated time.Time) *LatchStatusResponse {
+	response := &LatchStatusResponse{}
+	json := fmt.Sprintf(`{"data":{"operations":{"MyAppID":{"status":"on","two_factor":{"token":%q,"generated":%d}}}}}`, token, generated.UnixNano()/int64(time.Millisecond))
+	if err := response.Unmarshal(json); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func TestOtpVerifier(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryOtpChallengeStore()
+	verifier := NewOtpVerifier(store)
+	verifier.Clock = FixedClock(now)
+
+	challenge, err := verifier.NewChallenge(ctx, newTwoFactorResponse(t, "X4K9PQ", now.Add(-time.Minute)))
+	if err != nil {
+		t.Fatalf("NewChallenge() failed: unexpected error %v", err)
+	}
+	if challenge.ID == "" || !challenge.Expires.Equal(challenge.Generated.Add(DEFAULT_OTP_VALIDITY)) || store.Len() != 1 {
+		t.Errorf("NewChallenge() failed: unexpected challenge %+v", challenge)
+	}
+
+	if err := verifier.Verify(ctx, challenge.ID, "X4K9PP"); !errors.Is(err, ErrInvalidOtp) {
+		t.Errorf("Verify() failed: expected ErrInvalidOtp, got %v", err)
+	}
+	if err := verifier.Verify(ctx, challenge.ID, " x4k9pq "); err != nil {
+		t.Errorf("Verify() failed: expected the one time password to be valid, got %v", err)
+	}
+	if err := verifier.Verify(ctx, challenge.ID, "X4K9PQ"); !errors.Is(err, ErrOtpChallengeNotFound) {
+		t.Errorf("Verify() failed: expected challenges to be verified only once, got %v", err)
+	}
+
+	//Responses without token and expired tokens
+	if _, err := verifier.NewChallenge(ctx, newTwoFactorResponse(t, "", now)); !errors.Is(err, ErrNoTwoFactor) {
+		t.Errorf("NewChallenge() failed: expected ErrNoTwoFactor, got %v", err)
+	}
+	if _, err := verifier.NewChallenge(ctx, newTwoFactorResponse(t, "X4K9PQ", now.Add(-DEFAULT_OTP_VALIDITY))); !errors.Is(err, ErrOtpChallengeExpired) {
+		t.Errorf("NewChallenge() failed: expected ErrOtpChallengeExpired for an old token, got %v", err)
+	}
+	verifier.Validity = 30 * time.Second
+	challenge, _ = verifier.NewChallenge(ctx, newTwoFactorResponse(t, "X4K9PQ", now))
+	verifier.Clock = FixedClock(now.Add(31 * time.Second))
+	if err := verifier.Verify(ctx, challenge.ID, "X4K9PQ"); !errors.Is(err, ErrOtpChallengeExpired) || store.Len() != 0 {
+		t.Errorf("Verify() failed: expected expired challenge to be deleted, got %v", err)
+	}
+}
+
+func TestOtpVerifierMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	verifier := NewOtpVerifier(nil)
+	verifier.MaxAttempts = 2
+
+	challenge, err := verifier.NewChallenge(ctx, newTwoFactorResponse(t, "X4K9PQ", time.Now()))
+	if err != nil {
+		t.Fatalf("NewChallenge() failed: unexpected error %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := verifier.Verify(ctx, challenge.ID, "000000"); !errors.Is(err, ErrInvalidOtp) {
+			t.Errorf("Verify() failed: expected ErrInvalidOtp for attempt %d, got %v", i+1, err)
+		}
+	}
+	if err := verifier.Verify(ctx, challenge.ID, "X4K9PQ"); !errors.Is(err, ErrTooManyOtpAttempts) {
+		t.Errorf("Verify() failed: expected ErrTooManyOtpAttempts after %d attempts, got %v", verifier.MaxAttempts, err)
+	}
+}
+
+func TestOtpVerifierSweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2015, time.February, 15, 14, 53, 0, 0, time.UTC)
+	store := NewMemoryOtpChallengeStore()
+	verifier := NewOtpVerifier(store)
+	verifier.Clock = FixedClock(now)
+
+	//Expired challenges are removed by the clock of the verifier, not by the system clock
+	verifier.NewChallenge(ctx, newTwoFactorResponse(t, "X4K9PQ", now))
+	verifier.NewChallenge(ctx, newTwoFactorResponse(t, "P7R2MZ", now))
+	if store.Len() != 2 {
+		t.Errorf("NewChallenge() failed: expected 2 challenges, got %d", store.Len())
+	}
+
+	now = now.Add(DEFAULT_OTP_VALIDITY)
+	verifier.Clock = FixedClock(now)
+	if _, err := verifier.NewChallenge(ctx, newTwoFactorResponse(t, "Q3W8ER", now)); err != nil || store.Len() != 1 {
+		t.Errorf("NewChallenge() failed: expected expired challenges to be removed, got %d challenges (error %v)", store.Len(), err)
+	}
+}
@@ -0,0 +1,153 @@
+package golatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+//Error matched (using errors.Is()) by the errors returned when a request exceeds the client-side rate limit
+var ErrRateLimitExceeded = errors.New("Latch client rate limit exceeded")
+
+//Error returned when a request is rejected because the rate limit of the client has been exhausted
+type RateLimitError struct {
+	//Action of the rejected request
+	Action string
+	//Time until a new request would be allowed
+	RetryAfter time.Duration
+}
+
+//Implementation of the error interface
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s (action %q, retry after %v)", ErrRateLimitExceeded, e.Action, e.RetryAfter)
+}
+
+//Makes errors.Is(err, ErrRateLimitExceeded) work for rate limit errors
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimitExceeded
+}
+
+//Token bucket rate limiter. It is safe for concurrent use and can be shared between clients
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+//Returns a new rate limiter that allows rate requests per second with bursts of up to burst requests
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+//Takes a token if one is available. Otherwise returns false and the time until the next token is available
+func (r *RateLimiter) take() (bool, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.tokens = math.Min(r.burst, r.tokens+now.Sub(r.last).Seconds()*r.rate)
+	r.last = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return true, 0
+	}
+	if r.rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+
+	return false, time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+}
+
+//Returns a token taken for a request that wasn't sent
+func (r *RateLimiter) giveBack() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens = math.Min(r.burst, r.tokens+1)
+}
+
+//Takes a token if one is available without blocking
+func (r *RateLimiter) Allow() bool {
+	allowed, _ := r.take()
+	return allowed
+}
+
+//Blocks until a token is available or the context is done
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		allowed, delay := r.take()
+		if allowed {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return contextError(ctx, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+//Rate limits applied to the requests sent to the API
+type RateLimitPolicy struct {
+	//Limiter applied to all requests
+	Global *RateLimiter
+	//Limiters applied to specific actions (API_CHECK_STATUS_ACTION, API_HISTORY_ACTION...)
+	//The same limiter can be used for several actions (for example API_OPERATION_ACTION and API_APPLICATION_ACTION)
+	Actions map[string]*RateLimiter
+	//Return a RateLimitError instead of waiting when there are no tokens available
+	FailFast bool
+}
+
+//Sets the rate limits applied to all requests to the API (nil disables rate limiting)
+func (l *LatchAPI) SetRateLimit(policy *RateLimitPolicy) {
+	l.RateLimit = policy
+}
+
+//Waits until the request is allowed by the action and global limiters (or fails if FailFast is set)
+func (p *RateLimitPolicy) wait(ctx context.Context, request *LatchRequest) error {
+	if p == nil {
+		return nil
+	}
+
+	var taken []*RateLimiter
+	for _, limiter := range []*RateLimiter{p.Actions[request.Action], p.Global} {
+		if limiter == nil {
+			continue
+		}
+
+		var err error
+		if !p.FailFast {
+			err = limiter.Wait(ctx)
+		} else if allowed, delay := limiter.take(); !allowed {
+			err = &RateLimitError{Action: request.Action, RetryAfter: delay}
+		}
+		if err != nil {
+			//The request won't be sent, so the tokens taken from the other limiters are given back
+			for _, l := range taken {
+				l.giveBack()
+			}
+			return err
+		}
+		taken = append(taken, limiter)
+	}
+
+	return nil
+}
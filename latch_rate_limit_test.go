@@ -0,0 +1,95 @@
+package golatch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	limiter := NewRateLimiter(10, 2)
+
+	if !limiter.Allow() || !limiter.Allow() {
+		t.Errorf("RateLimiter.Allow() failed: expected burst of 2 requests to be allowed")
+	}
+	if limiter.Allow() {
+		t.Errorf("RateLimiter.Allow() failed: expected third request to be rejected")
+	}
+
+	time.Sleep(120 * time.Millisecond)
+	if !limiter.Allow() {
+		t.Errorf("RateLimiter.Allow() failed: expected a new token after 100ms")
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := NewRateLimiter(20, 1)
+	limiter.Allow()
+
+	start := time.Now()
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Errorf("RateLimiter.Wait() failed: unexpected error %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("RateLimiter.Wait() failed: expected to wait around 50ms, waited %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	limiter = NewRateLimiter(0.1, 1)
+	limiter.Allow()
+	if err := limiter.Wait(ctx); !errors.Is(err, ErrRequestTimeout) {
+		t.Errorf("RateLimiter.Wait() failed: expected ErrRequestTimeout, got %v", err)
+	}
+}
+
+func TestDoRequestRateLimit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"data":{"operations":{"MyAppID":{"status":"on"}}}}`))
+	}))
+	defer server.Close()
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+	latch.SetRateLimit(&RateLimitPolicy{
+		Actions:  map[string]*RateLimiter{API_HISTORY_ACTION: NewRateLimiter(0.1, 1)},
+		FailFast: true,
+	})
+
+	if _, err := latch.History("MyAccountID", time.Time{}, time.Time{}); err != nil {
+		t.Fatalf("History() failed: unexpected error %v", err)
+	}
+
+	_, err := latch.History("MyAccountID", time.Time{}, time.Time{})
+	var rate_limit_error *RateLimitError
+	if !errors.Is(err, ErrRateLimitExceeded) || !errors.As(err, &rate_limit_error) || rate_limit_error.Action != API_HISTORY_ACTION {
+		t.Errorf("History() failed: expected RateLimitError for action %q, got %v", API_HISTORY_ACTION, err)
+	}
+
+	if _, err := latch.Status("MyAccountID", false, false); err != nil {
+		t.Errorf("Status() failed: status requests should not be limited by the history limiter, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("DoRequest() failed: expected 2 requests to reach the server, got %d", requests)
+	}
+}
+
+func TestRateLimitPolicyGivesTokensBack(t *testing.T) {
+	status := NewRateLimiter(0.001, 1)
+	global := NewRateLimiter(0.001, 1)
+	global.Allow()
+	policy := &RateLimitPolicy{Global: global, Actions: map[string]*RateLimiter{API_CHECK_STATUS_ACTION: status}, FailFast: true}
+
+	request := &LatchRequest{Action: API_CHECK_STATUS_ACTION}
+	if err := policy.wait(context.Background(), request); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Fatalf("RateLimitPolicy.wait() failed: expected ErrRateLimitExceeded, got %v", err)
+	}
+	if !status.Allow() {
+		t.Errorf("RateLimitPolicy.wait() failed: expected the token of the action limiter to be given back when the global limiter rejects the request")
+	}
+}
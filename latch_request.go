@@ -2,8 +2,7 @@ package golatch
 
 import (
 	"bytes"
-	"crypto/hmac"
-	"crypto/sha1"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -15,6 +14,9 @@ import (
 )
 
 type LatchRequest struct {
+	//Action (status, pair, history...) and version of the API being called when known
+	Action     string
+	APIVersion string
 	AppID      string
 	SecretKey  string
 	HttpMethod string
@@ -22,6 +24,8 @@ type LatchRequest struct {
 	XHeaders   map[string]string
 	Params     url.Values
 	Date       time.Time
+	//Signer used instead of the secret key (if set)
+	Signer Signer
 }
 
 //Returns a new LatchRequest initialized with the parameters provided
@@ -39,29 +43,57 @@ func NewLatchRequest(appID string, secretKey string, httpMethod string, url *url
 
 //Gets the authentication headers (Authorization and Date)
 func (l *LatchRequest) GetAuthenticationHeaders() (headers map[string]string) {
+	headers, _ = l.GetAuthenticationHeadersWithContext(context.Background())
+	return headers
+}
+
+//Same as GetAuthenticationHeaders() but using a context that can cancel the signing of the request, returning the error of the signer (if any)
+func (l *LatchRequest) GetAuthenticationHeadersWithContext(ctx context.Context) (headers map[string]string, err error) {
 	headers = make(map[string]string)
-	headers[API_AUTHORIZATION_HEADER_NAME] = l.GetAuthorizationHeader()
+	headers[API_AUTHORIZATION_HEADER_NAME], err = l.GetAuthorizationHeaderWithContext(ctx)
 	headers[API_DATE_HEADER_NAME] = l.GetFormattedDate()
 
-	return headers
+	return headers, err
 }
 
 //Gets the Authorization header
 func (l *LatchRequest) GetAuthorizationHeader() string {
+	header, _ := l.GetAuthorizationHeaderWithContext(context.Background())
+	return header
+}
+
+//Same as GetAuthorizationHeader() but using a context that can cancel the signing of the request, returning the error of the signer (if any)
+func (l *LatchRequest) GetAuthorizationHeaderWithContext(ctx context.Context) (string, error) {
+	signature, err := l.GetSignedRequestSignatureWithContext(ctx)
 	return fmt.Sprint(API_AUTHENTICATION_METHOD,
 		API_AUTHORIZATION_HEADER_FIELD_SEPARATOR,
 		l.AppID,
 		API_AUTHORIZATION_HEADER_FIELD_SEPARATOR,
-		l.GetSignedRequestSignature())
+		signature), err
 }
 
-//Gets the signed request signature using HMAC-SHA1 (base64-encoded)
+//Gets the signed request signature using HMAC-SHA1 or the signer of the request (base64-encoded)
 func (l *LatchRequest) GetSignedRequestSignature() string {
-	key := []byte(l.SecretKey)
-	h := hmac.New(sha1.New, key)
-	h.Write([]byte(l.GetRequestSignature()))
+	signature, _ := l.GetSignedRequestSignatureWithContext(context.Background())
+	return signature
+}
+
+//Same as GetSignedRequestSignature() but using a context that can cancel the signing of the request, returning the error of the signer (if any)
+func (l *LatchRequest) GetSignedRequestSignatureWithContext(ctx context.Context) (string, error) {
+	signature, err := l.GetSigner().Sign(ctx, []byte(l.GetRequestSignature()))
+	if err != nil {
+		return "", signingError(err)
+	}
 
-	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+//Gets the signer of the request (an HMAC-SHA1 signer with the secret key if none has been set)
+func (l *LatchRequest) GetSigner() Signer {
+	if l.Signer != nil {
+		return l.Signer
+	}
+	return NewHMACSigner(l.SecretKey)
 }
 
 //Gets the request signature
@@ -135,6 +167,13 @@ func (l *LatchRequest) GetFormattedDate() string {
 
 //Gets the HTTP request for this Latch Request
 func (l *LatchRequest) GetHttpRequest() *http.Request {
+	request, _ := l.GetHttpRequestWithContext(context.Background())
+	return request
+}
+
+//Same as GetHttpRequest() but using a context that can cancel the signing of the request, returning the error of the signer (if any)
+//The context is also set as the context of the HTTP request
+func (l *LatchRequest) GetHttpRequestWithContext(ctx context.Context) (*http.Request, error) {
 	var body io.Reader = nil
 
 	//Include parameters for POST and PUT methods
@@ -142,10 +181,16 @@ func (l *LatchRequest) GetHttpRequest() *http.Request {
 		body = strings.NewReader(l.Params.Encode())
 	}
 
-	request, _ := http.NewRequest(l.HttpMethod, l.URL.String(), body)
+	request, err := http.NewRequestWithContext(ctx, l.HttpMethod, l.URL.String(), body)
+	if err != nil {
+		return nil, err
+	}
 
-	//Set Headers
-	headers := l.GetAuthenticationHeaders()
+	//Set Headers (line breaks are removed from the X-11Paths headers, like in the signature)
+	for header, value := range l.XHeaders {
+		request.Header.Set(header, strings.Replace(value, "\n", " ", -1))
+	}
+	headers, err := l.GetAuthenticationHeadersWithContext(ctx)
 	for header, value := range headers {
 		request.Header.Set(header, value)
 	}
@@ -154,5 +199,5 @@ func (l *LatchRequest) GetHttpRequest() *http.Request {
 	}
 	request.Header.Set("User-Agent", HTTP_USER_AGENT)
 
-	return request
+	return request, err
 }
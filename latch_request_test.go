@@ -117,4 +117,7 @@ func TestGetHttpRequest(t *testing.T) {
 	if got_request.Header.Get(API_DATE_HEADER_NAME) != example_request.GetFormattedDate() {
 		t.Errorf("GetHttpRequest() failed: expected Date header %q, got %q", got_request.Header.Get(API_DATE_HEADER_NAME), example_request.GetFormattedDate())
 	}
+	if got_request.Header.Get("X-11paths-A") != "Line Breaks" || got_request.Header.Get("X-11paths-B") != "Test value" {
+		t.Errorf("GetHttpRequest() failed: expected X-11Paths headers to be set, got %v", got_request.Header)
+	}
 }
@@ -2,6 +2,8 @@ package golatch
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"strings"
 )
 
@@ -56,6 +58,41 @@ type LatchOperation struct {
 	Operations    map[string]LatchOperation `json:"operations"`
 }
 
+type LatchAddInstanceResponse struct {
+	Data struct {
+		Instances map[string]string `json:"instances"`
+	} `json:"data"`
+}
+
+type LatchShowInstancesResponse struct {
+	Data struct {
+		Instances map[string]LatchInstance `json:"instances"`
+	} `json:"data"`
+}
+
+type LatchInstance struct {
+	Name string `json:"name"`
+}
+
+type LatchTotpResponse struct {
+	Data LatchTotp `json:"data"`
+}
+
+type LatchTotp struct {
+	TotpId     string   `json:"totpId"`
+	Secret     string   `json:"secret"`
+	AppId      string   `json:"appId"`
+	Identity   string   `json:"identity"`
+	Issuer     string   `json:"issuer"`
+	Algorithm  string   `json:"algorithm"`
+	Digits     int      `json:"digits"`
+	Period     int      `json:"period"`
+	CreatedAt  int64    `json:"createdAt"`
+	DisabledBy []string `json:"disabledBy"`
+	QR         string   `json:"qr"`
+	URI        string   `json:"uri"`
+}
+
 type LatchHistoryResponse struct {
 	AppID string
 	Data  struct {
@@ -150,6 +187,18 @@ func (l *LatchShowOperationResponse) Unmarshal(Json string) (err error) {
 	return json.Unmarshal([]byte(Json), l)
 }
 
+func (l *LatchAddInstanceResponse) Unmarshal(Json string) (err error) {
+	return json.Unmarshal([]byte(Json), l)
+}
+
+func (l *LatchShowInstancesResponse) Unmarshal(Json string) (err error) {
+	return json.Unmarshal([]byte(Json), l)
+}
+
+func (l *LatchTotpResponse) Unmarshal(Json string) (err error) {
+	return json.Unmarshal([]byte(Json), l)
+}
+
 func (l *LatchHistoryResponse) Unmarshal(Json string) (err error) {
 	return json.Unmarshal([]byte(strings.Replace(Json, l.AppID, "application", 1)), l)
 }
@@ -192,6 +241,58 @@ func (l *LatchShowOperationResponse) FirstOperation() (operationId string, opera
 	return
 }
 
+func (l *LatchAddInstanceResponse) Instances() map[string]string {
+	return l.Data.Instances
+}
+
+func (l *LatchAddInstanceResponse) InstanceId() (instanceId string) {
+	for instanceId = range l.Data.Instances {
+		break
+	}
+	return
+}
+
+func (l *LatchShowInstancesResponse) Instances() map[string]LatchInstance {
+	return l.Data.Instances
+}
+
+func (l *LatchTotpResponse) Totp() LatchTotp {
+	return l.Data
+}
+
+func (l *LatchTotpResponse) TotpId() string {
+	return l.Data.TotpId
+}
+
+//Gets the otpauth:// URI used to provision the TOTP in an authenticator app
+//If the API didn't include it in the response it's built from the TOTP parameters
+func (t LatchTotp) ProvisioningURI() string {
+	if t.URI != "" {
+		return t.URI
+	}
+
+	params := url.Values{}
+	params.Set("secret", t.Secret)
+	if t.Issuer != "" {
+		params.Set("issuer", t.Issuer)
+	}
+	if t.Algorithm != "" {
+		params.Set("algorithm", t.Algorithm)
+	}
+	if t.Digits > 0 {
+		params.Set("digits", fmt.Sprint(t.Digits))
+	}
+	if t.Period > 0 {
+		params.Set("period", fmt.Sprint(t.Period))
+	}
+
+	label := url.PathEscape(t.Identity)
+	if t.Issuer != "" {
+		label = url.PathEscape(t.Issuer) + ":" + label
+	}
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
 func (l *LatchHistoryResponse) Application() LatchApplication {
 	return l.Data.Application
 }
@@ -94,6 +94,55 @@ func TestLatchShowOperationResponseUnmarshal(t *testing.T) {
 	}
 }
 
+func TestLatchAddInstanceResponseUnmarshal(t *testing.T) {
+	json := `{"data":{"instances":{"hJkM4qd3UmxYHcQ8WbZe":"My Instance"}}}`
+	response := &LatchAddInstanceResponse{}
+
+	err := response.Unmarshal(json)
+
+	if err != nil {
+		t.Errorf("LatchAddInstanceResponse.Unmarshal() failed json: %q , error %q", json, err)
+	}
+	if response.InstanceId() != "hJkM4qd3UmxYHcQ8WbZe" || response.Instances()["hJkM4qd3UmxYHcQ8WbZe"] != "My Instance" {
+		t.Errorf("LatchAddInstanceResponse.Unmarshal() failed json: %q , object %q", json, response)
+	}
+}
+
+func TestLatchShowInstancesResponseUnmarshal(t *testing.T) {
+	json := `{"data":{"instances":{"hJkM4qd3UmxYHcQ8WbZe":{"name":"My Instance"},"bQ2nGh8ZsTyPd6VkLmWx":{"name":"My Other Instance"}}}}`
+	response := &LatchShowInstancesResponse{}
+
+	err := response.Unmarshal(json)
+
+	if err != nil {
+		t.Errorf("LatchShowInstancesResponse.Unmarshal() failed json: %q , error %q", json, err)
+	}
+	if instances := response.Instances(); len(instances) != 2 || instances["bQ2nGh8ZsTyPd6VkLmWx"].Name != "My Other Instance" {
+		t.Errorf("LatchShowInstancesResponse.Unmarshal() failed json: %q , object %v", json, response)
+	}
+}
+
+func TestLatchTotpResponseUnmarshal(t *testing.T) {
+	json := `{"data":{"totpId":"Ht4FgSCn2XHA3Jk9wbQE","secret":"JBSWY3DPEHPK3PXP","appId":"MyAppID","identity":"me@example.com","issuer":"My App","algorithm":"SHA1","digits":6,"period":30,"createdAt":1428528090941,"disabledBy":[],"qr":"data:image/png;base64,iVBORw0KGgo=","uri":"otpauth://totp/My%20App:me@example.com?secret=JBSWY3DPEHPK3PXP"}}`
+	response := &LatchTotpResponse{}
+
+	err := response.Unmarshal(json)
+
+	if err != nil {
+		t.Errorf("LatchTotpResponse.Unmarshal() failed json: %q , error %q", json, err)
+	}
+	if totp := response.Totp(); response.TotpId() != "Ht4FgSCn2XHA3Jk9wbQE" || totp.Secret != "JBSWY3DPEHPK3PXP" || totp.Digits != 6 || totp.Period != 30 || totp.ProvisioningURI() != totp.URI {
+		t.Errorf("LatchTotpResponse.Unmarshal() failed json: %q , object %+v", json, response)
+	}
+
+	//The URI is built from the TOTP parameters when it's missing
+	totp := LatchTotp{Secret: "JBSWY3DPEHPK3PXP", Identity: "me@example.com", Issuer: "My App", Algorithm: "SHA1", Digits: 6, Period: 30}
+	expected_uri := "otpauth://totp/My%20App:me@example.com?algorithm=SHA1&digits=6&issuer=My+App&period=30&secret=JBSWY3DPEHPK3PXP"
+	if uri := totp.ProvisioningURI(); uri != expected_uri {
+		t.Errorf("LatchTotp.ProvisioningURI() failed: expected %q, got %q", expected_uri, uri)
+	}
+}
+
 func TestLatchHistoryResponseUnmarshal(t *testing.T) {
 	json := `{"data":{"2Wv8UqaT6iZRQEbyG9Kv":{"status":"on","pairedOn":1428528090941,"name":"GoLatch Test","description":"","imageURL":"https://s3-eu-west-1.amazonaws.com/latch-ireland/avatar1.jpg","contactPhone":"666111222","contactEmail":"","two_factor":"DISABLED","lock_on_request":"DISABLED","operations":{"wJrfCBzZCtiZfVFwt9aJ":{"name":"Operation 1","status":"on","two_factor":"off","lock_on_request":"off","operations":{}}}},"lastSeen":1428858456785,"clientVersion":[{"platform":"Android","app":"1.5.1"}],"count":5,"history":[{"t":1428528254424,"action":"get","what":"status","value":"on","was":"-","name":"GoLatch Test","userAgent":"Go 1.1 package http","ip":"127.0.0.1"},{"t":1428528260264,"action":"USER_UPDATE","what":"status","value":"off","was":"on","name":"GoLatch Test","userAgent":"","ip":"127.0.0.1"},{"t":1428528264520,"action":"get","what":"status","value":"off","was":"-","name":"GoLatch Test","userAgent":"Go 1.1 package http","ip":"127.0.0.1"},{"t":1428528274326,"action":"USER_UPDATE","what":"status","value":"on","was":"off","name":"GoLatch Test","userAgent":"","ip":"127.0.0.1"},{"t":1428528277313,"action":"get","what":"status","value":"on","was":"-","name":"GoLatch Test","userAgent":"Go 1.1 package http","ip":"127.0.0.1"}]}}`
 	response := &LatchHistoryResponse{AppID: "2Wv8UqaT6iZRQEbyG9Kv"}
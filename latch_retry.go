@@ -0,0 +1,117 @@
+package golatch
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+//Policy used to retry failed requests with exponential backoff and jitter
+//By default only idempotent requests (see LatchRequest.IsIdempotent()) are retried, and only when they fail because of a network error or a 5xx/429 HTTP status code
+type RetryPolicy struct {
+	//Max number of attempts (including the first one)
+	MaxAttempts int
+	//Time to wait before the first retry
+	InitialBackoff time.Duration
+	//Max time to wait between attempts
+	MaxBackoff time.Duration
+	//Factor by which the backoff is multiplied after each attempt
+	Multiplier float64
+	//Fraction of the backoff that is randomized (0 means no jitter, 1 means anything between 0 and the backoff)
+	Jitter float64
+	//Retry non idempotent requests (PUT, POST, DELETE, pairing and unpairing requests) too
+	RetryNonIdempotent bool
+	//Decides if a failed request can be retried (replaces the default conditions when set)
+	Retryable func(request *LatchRequest, err error) bool
+}
+
+//Returns a retry policy with sensible default values (3 attempts, starting with a 100ms backoff)
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}
+}
+
+//Sets the retry policy used in all requests to the API (nil disables retries)
+func (l *LatchAPI) SetRetryPolicy(policy *RetryPolicy) {
+	l.RetryPolicy = policy
+}
+
+//Default retry conditions: network errors and HTTP errors caused by the server or by too many requests
+//Errors returned by the Latch API (LatchError) are never retried
+func IsRetryableError(err error) bool {
+	var http_error *LatchHttpError
+	var latch_error *LatchError
+
+	switch {
+	case err == nil, errors.As(err, &latch_error):
+		return false
+	case errors.As(err, &http_error):
+		return http_error.IsServerError() || http_error.StatusCode == http.StatusTooManyRequests
+	}
+
+	return true
+}
+
+//Gets the time to wait after the attempt provided (starting at 1) before trying again
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff -= backoff * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+
+	return time.Duration(backoff)
+}
+
+//Checks if a request should be retried after the attempt provided failed with err
+func (p *RetryPolicy) shouldRetry(ctx context.Context, request *LatchRequest, attempt int, err error) bool {
+	if p == nil || err == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+	if !request.IsIdempotent() && !p.RetryNonIdempotent {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(request, err)
+	}
+
+	return IsRetryableError(err)
+}
+
+//Checks if the request can be sent again safely: GET requests, except the ones that pair and unpair accounts
+//(a pairing token can only be used once, and retrying a pairing or an unpairing that succeeded would fail or pair the account again)
+func (l *LatchRequest) IsIdempotent() bool {
+	switch l.Action {
+	case API_PAIR_ACTION, API_PAIR_WITH_ID_ACTION, API_UNPAIR_ACTION:
+		return false
+	}
+	return l.HttpMethod == HTTP_METHOD_GET
+}
+
+//Waits before the next attempt, returning early with an error if the context is done
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.Backoff(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
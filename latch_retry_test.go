@@ -0,0 +1,177 @@
+package golatch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//Test server that fails with the status code provided until the number of failures is reached
+func newFailingTestServer(failures int, statusCode int, dates *[]string) *httptest.Server {
+	attempts := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*dates = append(*dates, r.Header.Get(API_DATE_HEADER_NAME))
+		if attempts++; attempts <= failures {
+			w.WriteHeader(statusCode)
+			return
+		}
+		w.Write([]byte(`{"data":{"operations":{"MyAppID":{"status":"on"}}}}`))
+	}))
+}
+
+func newTestRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2}
+}
+
+func TestDoRequestRetries(t *testing.T) {
+	var dates []string
+	server := newFailingTestServer(2, http.StatusBadGateway, &dates)
+	defer server.Close()
+
+	api := &LatchAPI{}
+	api.SetRetryPolicy(newTestRetryPolicy())
+
+	request := newTestRequest(server.URL)
+	request.Date = time.Date(2015, time.February, 15, 14, 53, 0, 0, time.UTC)
+	response, err := api.DoRequest(request, &LatchStatusResponse{})
+	if err != nil || (*response).(*LatchStatusResponse).Status() != LATCH_STATUS_ON {
+		t.Fatalf("DoRequest() failed: expected success after retries, got error %v", err)
+	}
+	if len(dates) != 3 {
+		t.Fatalf("DoRequest() failed: expected 3 attempts, got %d", len(dates))
+	}
+	if dates[0] != "2015-02-15 14:53:00" || dates[1] == dates[0] {
+		t.Errorf("DoRequest() failed: expected retries to be signed with a fresh date, got %q", dates)
+	}
+}
+
+func TestDoRequestRetriesExhausted(t *testing.T) {
+	var dates []string
+	server := newFailingTestServer(5, http.StatusServiceUnavailable, &dates)
+	defer server.Close()
+
+	api := &LatchAPI{RetryPolicy: newTestRetryPolicy()}
+	_, err := api.DoRequest(newTestRequest(server.URL), &LatchStatusResponse{})
+
+	var http_error *LatchHttpError
+	if !errors.As(err, &http_error) || http_error.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("DoRequest() failed: expected the last HTTP error, got %v", err)
+	}
+	if len(dates) != 3 {
+		t.Errorf("DoRequest() failed: expected 3 attempts, got %d", len(dates))
+	}
+}
+
+func TestDoRequestDoesNotRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		action     string
+		statusCode int
+		policy     *RetryPolicy
+	}{
+		{"no policy", HTTP_METHOD_GET, "", http.StatusServiceUnavailable, nil},
+		{"client error", HTTP_METHOD_GET, "", http.StatusNotFound, newTestRetryPolicy()},
+		{"non idempotent", HTTP_METHOD_POST, "", http.StatusServiceUnavailable, newTestRetryPolicy()},
+		{"pair", HTTP_METHOD_GET, API_PAIR_ACTION, http.StatusServiceUnavailable, newTestRetryPolicy()},
+		{"pair with id", HTTP_METHOD_GET, API_PAIR_WITH_ID_ACTION, http.StatusServiceUnavailable, newTestRetryPolicy()},
+		{"unpair", HTTP_METHOD_GET, API_UNPAIR_ACTION, http.StatusServiceUnavailable, newTestRetryPolicy()},
+	}
+
+	for _, test := range tests {
+		var dates []string
+		server := newFailingTestServer(1, test.statusCode, &dates)
+
+		request := newTestRequest(server.URL)
+		request.HttpMethod = test.method
+		request.Action = test.action
+		api := &LatchAPI{RetryPolicy: test.policy}
+		if _, err := api.DoRequest(request, nil); err == nil || len(dates) != 1 {
+			t.Errorf("DoRequest() failed (%s): expected a single failed attempt, got %d attempts (error %v)", test.name, len(dates), err)
+		}
+
+		server.Close()
+	}
+}
+
+func TestDoRequestRetriesNonIdempotent(t *testing.T) {
+	var dates []string
+	server := newFailingTestServer(1, http.StatusServiceUnavailable, &dates)
+	defer server.Close()
+
+	policy := newTestRetryPolicy()
+	policy.RetryNonIdempotent = true
+	api := &LatchAPI{RetryPolicy: policy}
+
+	request := newTestRequest(server.URL)
+	request.HttpMethod = HTTP_METHOD_PUT
+	if _, err := api.DoRequest(request, nil); err != nil || len(dates) != 2 {
+		t.Errorf("DoRequest() failed: expected success on the second attempt, got %d attempts (error %v)", len(dates), err)
+	}
+
+	dates = nil
+	server = newFailingTestServer(1, http.StatusServiceUnavailable, &dates)
+	defer server.Close()
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+	latch.SetRetryPolicy(policy)
+	if _, err := latch.Pair("MyToken"); err != nil || len(dates) != 2 {
+		t.Errorf("Pair() failed: expected success on the second attempt, got %d attempts (error %v)", len(dates), err)
+	}
+}
+
+func TestDoRequestRetryCanceled(t *testing.T) {
+	var dates []string
+	server := newFailingTestServer(5, http.StatusServiceUnavailable, &dates)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	api := &LatchAPI{RetryPolicy: &RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second}}
+	_, err := api.DoRequestWithContext(ctx, newTestRequest(server.URL), nil)
+	if !errors.Is(err, ErrRequestTimeout) || len(dates) != 1 {
+		t.Errorf("DoRequestWithContext() failed: expected timeout while waiting to retry, got %d attempts (error %v)", len(dates), err)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i, backoff := range expected {
+		if got := policy.Backoff(i + 1); got != backoff {
+			t.Errorf("RetryPolicy.Backoff() failed: expected %v for attempt %d, got %v", backoff, i+1, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(1); got < 50*time.Millisecond || got > 100*time.Millisecond {
+			t.Fatalf("RetryPolicy.Backoff() failed: expected jittered backoff between 50ms and 100ms, got %v", got)
+		}
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{nil, false},
+		{ErrAccountNotPaired, false},
+		{&LatchHttpError{StatusCode: 400}, false},
+		{&LatchHttpError{StatusCode: 429}, true},
+		{&LatchHttpError{StatusCode: 500}, true},
+		{errors.New("connection reset by peer"), true},
+	}
+
+	for _, test := range tests {
+		if got := IsRetryableError(test.err); got != test.retryable {
+			t.Errorf("IsRetryableError() failed: expected %v for %v, got %v", test.retryable, test.err, got)
+		}
+	}
+}
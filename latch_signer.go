@@ -0,0 +1,46 @@
+package golatch
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+)
+
+//Error returned when a request can't be signed
+var ErrSigningFailed = errors.New("unable to sign the request")
+
+//Signs the canonical string of the requests (see LatchRequest.GetRequestSignature())
+//Implement it to keep the secret keys out of the process (in a signing service or HSM for example)
+type Signer interface {
+	//Returns the raw signature of the message (it will be base64-encoded in the Authorization header)
+	Sign(ctx context.Context, message []byte) ([]byte, error)
+}
+
+//Signer that computes the HMAC-SHA1 of the message with a secret key (the default one)
+type HMACSigner struct {
+	key []byte
+}
+
+//Returns a new HMAC-SHA1 signer for the secret key provided
+func NewHMACSigner(secretKey string) *HMACSigner {
+	return &HMACSigner{key: []byte(secretKey)}
+}
+
+//Implementation of the Signer interface
+func (s *HMACSigner) Sign(ctx context.Context, message []byte) ([]byte, error) {
+	h := hmac.New(sha1.New, s.key)
+	h.Write(message)
+	return h.Sum(nil), nil
+}
+
+//Sets the signer used to sign the requests instead of the secret key (nil uses the secret key again)
+func (l *LatchAPI) SetSigner(signer Signer) {
+	l.Signer = signer
+}
+
+//Wraps the errors returned by signers
+func signingError(err error) error {
+	return fmt.Errorf("%w: %w", ErrSigningFailed, err)
+}
@@ -0,0 +1,88 @@
+package golatch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+type testSigner struct {
+	messages []string
+	err      error
+}
+
+func (s *testSigner) Sign(ctx context.Context, message []byte) ([]byte, error) {
+	s.messages = append(s.messages, string(message))
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []byte("MySignature"), nil
+}
+
+func TestHMACSigner(t *testing.T) {
+	request := *example_request
+	request.SecretKey = ""
+	request.Signer = NewHMACSigner("MySecretKey")
+
+	if header := request.GetAuthorizationHeader(); header != example_expected_header {
+		t.Errorf("GetAuthorizationHeader() failed: expected %q, got %q", example_expected_header, header)
+	}
+	if _, ok := example_request.GetSigner().(*HMACSigner); !ok {
+		t.Errorf("GetSigner() failed: expected HMAC signer when no signer is set, got %T", example_request.GetSigner())
+	}
+}
+
+func TestSigner(t *testing.T) {
+	signer := &testSigner{}
+	request := *example_request
+	request.Signer = signer
+
+	header, err := request.GetAuthorizationHeaderWithContext(context.Background())
+	if err != nil || header != "11PATHS MyAppID TXlTaWduYXR1cmU=" {
+		t.Errorf("GetAuthorizationHeaderWithContext() failed: expected signature of the signer, got %q (error %v)", header, err)
+	}
+	if len(signer.messages) != 1 || signer.messages[0] != example_expected_signature {
+		t.Errorf("GetAuthorizationHeaderWithContext() failed: expected canonical string to be signed, got %q", signer.messages)
+	}
+
+	signer.err = errors.New("signer unavailable")
+	if _, err := request.GetHttpRequestWithContext(context.Background()); !errors.Is(err, ErrSigningFailed) || !errors.Is(err, signer.err) {
+		t.Errorf("GetHttpRequestWithContext() failed: expected ErrSigningFailed wrapping the error of the signer, got %v", err)
+	}
+}
+
+func TestLatchWithSigner(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get(API_AUTHORIZATION_HEADER_NAME) != "11PATHS MyAppID TXlTaWduYXR1cmU=" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	signer := &testSigner{}
+	latch := NewLatchWithSigner("MyAppID", signer)
+	latch.SetAPIURL(server.URL)
+	if err := latch.Lock("MyAccountID"); err != nil {
+		t.Errorf("Lock() failed: expected request signed by the signer, got error %v", err)
+	}
+
+	signer.err = errors.New("signer unavailable")
+	if err := latch.Lock("MyAccountID"); !errors.Is(err, ErrSigningFailed) {
+		t.Errorf("Lock() failed: expected ErrSigningFailed, got %v", err)
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Lock() failed: expected requests that can't be signed not to be sent, got %d requests", requests)
+	}
+
+	user := NewLatchUserWithSigner("MyUserID", &testSigner{})
+	if user.Signer == nil || user.SecretKey != "" {
+		t.Errorf("NewLatchUserWithSigner() failed: expected signer to be set, got %+v", user)
+	}
+}
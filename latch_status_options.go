@@ -0,0 +1,133 @@
+package golatch
+
+import (
+	"context"
+	"fmt"
+)
+
+//Options of the status requests
+type StatusOptions struct {
+	//Don't include the one time password in the response
+	NoOtp bool
+	//Don't send push notifications to the client (requires SILVER, GOLD or PLATINUM subscription)
+	Silent bool
+	//Get the status of an operation and/or an instance instead of the status of the account
+	OperationId string
+	InstanceId  string
+	//Extra X-11Paths headers sent (and signed) with the request, along with the ones of the client (see Latch.WithXHeaders())
+	XHeaders map[string]string
+	//Get the status from the API even if a status cache has been set (the response is not cached)
+	BypassCache bool
+}
+
+//Functional option of the status requests
+type StatusOption func(*StatusOptions)
+
+//Sets whether the one time password is left out of the response
+func WithNoOtp(nootp bool) StatusOption {
+	return func(o *StatusOptions) {
+		o.NoOtp = nootp
+	}
+}
+
+//Sets whether push notifications are sent to the client
+func WithSilent(silent bool) StatusOption {
+	return func(o *StatusOptions) {
+		o.Silent = silent
+	}
+}
+
+//Gets the status of an operation (an empty ID means the account)
+func WithOperation(operationId string) StatusOption {
+	return func(o *StatusOptions) {
+		o.OperationId = operationId
+	}
+}
+
+//Gets the status of an instance of the account or of the operation (an empty ID means no instance)
+func WithInstance(instanceId string) StatusOption {
+	return func(o *StatusOptions) {
+		o.InstanceId = instanceId
+	}
+}
+
+//Adds extra X-11Paths headers to the request (added to the ones set by previous options)
+func WithStatusXHeaders(headers map[string]string) StatusOption {
+	return func(o *StatusOptions) {
+		merged := make(map[string]string)
+		for name, value := range o.XHeaders {
+			merged[name] = value
+		}
+		for name, value := range headers {
+			merged[name] = value
+		}
+		o.XHeaders = merged
+	}
+}
+
+//Sets whether the status cache is bypassed
+func WithCacheBypass(bypass bool) StatusOption {
+	return func(o *StatusOptions) {
+		o.BypassCache = bypass
+	}
+}
+
+//Sets the default options of the status requests of the client (Status(), OperationStatus(), StatusWithOptions(), Guard checks...)
+//The options (or flags) of each call are applied after the default ones, so they can override them
+func (l *Latch) SetDefaultStatusOptions(options ...StatusOption) {
+	l.DefaultStatusOptions = options
+}
+
+//Gets the status of an account (or of an operation or instance, see WithOperation() and WithInstance()) using the default options
+//of the client (see SetDefaultStatusOptions()) and the options provided
+func (l *Latch) StatusWithOptions(ctx context.Context, accountId string, options ...StatusOption) (response *LatchStatusResponse, err error) {
+	return l.status(ctx, accountId, l.statusOptions(options...))
+}
+
+//Same as StatusWithOptions() for an operation, given it's account ID and operation ID (the options can still select an instance of the operation)
+func (l *Latch) OperationStatusWithOptions(ctx context.Context, accountId string, operationId string, options ...StatusOption) (response *LatchStatusResponse, err error) {
+	return l.StatusWithOptions(ctx, accountId, append([]StatusOption{WithOperation(operationId)}, options...)...)
+}
+
+//Applies the default options of the client and then the options provided
+func (l *Latch) statusOptions(options ...StatusOption) StatusOptions {
+	o := StatusOptions{}
+	for _, option := range l.DefaultStatusOptions {
+		option(&o)
+	}
+	for _, option := range options {
+		option(&o)
+	}
+	return o
+}
+
+//Key of the status requested with these options by a client with the extra headers provided in the status cache
+func (o StatusOptions) cacheKey(accountId string, xHeaders map[string]string) StatusCacheKey {
+	return StatusCacheKey{
+		AccountId:   accountId,
+		OperationId: o.OperationId,
+		InstanceId:  o.InstanceId,
+		NoOtp:       o.NoOtp,
+		Silent:      o.Silent,
+		XHeaders:    (&LatchRequest{XHeaders: mergeXHeaders(xHeaders, o.XHeaders)}).GetSerializedHeaders(),
+	}
+}
+
+//Performs a status request through the status cache (unless it's bypassed)
+func (l *Latch) status(ctx context.Context, accountId string, o StatusOptions) (*LatchStatusResponse, error) {
+	query := instanceQuery(API_CHECK_STATUS_ACTION, accountId, o.OperationId, o.InstanceId)
+	if o.NoOtp {
+		query = fmt.Sprint(query, "/", API_NOOTP_SUFFIX)
+	}
+	if o.Silent {
+		query = fmt.Sprint(query, "/", API_SILENT_SUFFIX)
+	}
+	if len(o.XHeaders) > 0 {
+		l = l.WithXHeaders(o.XHeaders)
+	}
+
+	if o.BypassCache {
+		return l.StatusRequestWithContext(ctx, query)
+	}
+	return l.cachedStatusRequest(ctx, o.cacheKey(accountId, l.XHeaders), query)
+}
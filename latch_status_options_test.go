@@ -0,0 +1,126 @@
+package golatch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusWithOptions(t *testing.T) {
+	var got_paths []string
+	var got_header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got_paths = append(got_paths, r.URL.Path)
+		got_header = r.Header
+		w.Write([]byte(`{"data":{"operations":{"MyAppID":{"status":"on"}}}}`))
+	}))
+	defer server.Close()
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+	latch.SetDefaultStatusOptions(WithNoOtp(true), WithStatusXHeaders(map[string]string{"X-11Paths-Tenant": "MyTenant"}))
+	ctx := context.Background()
+
+	tests := []struct {
+		options       []StatusOption
+		expected_path string
+	}{
+		{nil, "/api/1.0/status/MyAccountID/nootp"},
+		{[]StatusOption{WithNoOtp(false), WithSilent(true)}, "/api/1.0/status/MyAccountID/silent"},
+		{[]StatusOption{WithOperation("MyOperationID")}, "/api/1.0/status/MyAccountID/op/MyOperationID/nootp"},
+		{[]StatusOption{WithInstance("MyInstanceID")}, "/api/1.0/status/MyAccountID/i/MyInstanceID/nootp"},
+		{[]StatusOption{WithOperation("MyOperationID"), WithInstance("MyInstanceID"), WithSilent(true)}, "/api/1.0/status/MyAccountID/op/MyOperationID/i/MyInstanceID/nootp/silent"},
+	}
+	for _, test := range tests {
+		if response, err := latch.StatusWithOptions(ctx, "MyAccountID", test.options...); err != nil || response.Status() != LATCH_STATUS_ON {
+			t.Errorf("StatusWithOptions() failed: unexpected response %v (error %v)", response, err)
+		}
+		if got_path := got_paths[len(got_paths)-1]; got_path != test.expected_path {
+			t.Errorf("StatusWithOptions() failed: expected path %q, got %q", test.expected_path, got_path)
+		}
+	}
+
+	//Headers of the default options are merged with the ones of the call
+	latch.OperationStatusWithOptions(ctx, "MyAccountID", "MyOperationID", WithStatusXHeaders(map[string]string{"X-11Paths-Session": "MySession"}))
+	if got_header.Get("X-11Paths-Tenant") != "MyTenant" || got_header.Get("X-11Paths-Session") != "MySession" {
+		t.Errorf("OperationStatusWithOptions() failed: expected default and call headers, got %v", got_header)
+	}
+	if got_path := got_paths[len(got_paths)-1]; got_path != "/api/1.0/status/MyAccountID/op/MyOperationID/nootp" {
+		t.Errorf("OperationStatusWithOptions() failed: unexpected path %q", got_path)
+	}
+
+	//The positional methods use the default options too, but their flags, operation and instance override them
+	latch.SetDefaultStatusOptions(WithNoOtp(true), WithOperation("MyOperationID"), WithStatusXHeaders(map[string]string{"X-11Paths-Tenant": "MyTenant"}))
+	latch.Status("MyAccountID", false, true)
+	if got_path := got_paths[len(got_paths)-1]; got_path != "/api/1.0/status/MyAccountID/silent" || got_header.Get("X-11Paths-Tenant") != "MyTenant" {
+		t.Errorf("Status() failed: expected default options to be applied, got path %q and headers %v", got_path, got_header)
+	}
+	latch.InstanceStatus("MyAccountID", "", "MyInstanceID", true, false)
+	if got_path := got_paths[len(got_paths)-1]; got_path != "/api/1.0/status/MyAccountID/i/MyInstanceID/nootp" {
+		t.Errorf("InstanceStatus() failed: unexpected path %q", got_path)
+	}
+}
+
+func TestStatusWithOptionsCacheBypass(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"data":{"operations":{"MyAppID":{"status":"on"}}}}`))
+	}))
+	defer server.Close()
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+	latch.SetStatusCache(NewStatusCache(time.Minute))
+	ctx := context.Background()
+
+	latch.StatusWithOptions(ctx, "MyAccountID")
+	latch.StatusWithOptions(ctx, "MyAccountID")
+	if requests != 1 {
+		t.Errorf("StatusWithOptions() failed: expected cached response, got %d requests", requests)
+	}
+	latch.StatusWithOptions(ctx, "MyAccountID", WithCacheBypass(true))
+	if requests != 2 {
+		t.Errorf("StatusWithOptions() failed: expected the cache to be bypassed, got %d requests", requests)
+	}
+
+	latch.SetDefaultStatusOptions(WithCacheBypass(true))
+	latch.StatusWithOptions(ctx, "MyAccountID", WithCacheBypass(false))
+	if requests != 2 {
+		t.Errorf("StatusWithOptions() failed: expected the options of the call to override the default ones, got %d requests", requests)
+	}
+}
+
+func TestStatusCacheXHeaders(t *testing.T) {
+	got_values := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got_values = append(got_values, r.Header.Get("X-11Paths-Tenant"))
+		w.Write([]byte(`{"data":{"operations":{"MyAppID":{"status":"on"}}}}`))
+	}))
+	defer server.Close()
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+	latch.SetStatusCache(NewStatusCache(time.Minute))
+
+	//Statuses requested with different headers are cached separately, since the headers may change the response
+	for _, value := range []string{"a", "b", "a", "b"} {
+		latch.WithXHeaders(map[string]string{"X-11Paths-Tenant": value}).Status("MyAccountID", true, false)
+	}
+	latch.StatusWithOptions(context.Background(), "MyAccountID", WithNoOtp(true), WithStatusXHeaders(map[string]string{"X-11Paths-Tenant": "c"}))
+	latch.Status("MyAccountID", true, false)
+	latch.Status("MyAccountID", true, false)
+	if strings.Join(got_values, ",") != "a,b,c," {
+		t.Errorf("Status() failed: expected one request to the API for each set of headers, got %v", got_values)
+	}
+
+	//Locking the account through a copy with headers invalidates all of them
+	latch.WithXHeaders(map[string]string{"X-11Paths-Tenant": "a"}).Lock("MyAccountID")
+	latch.Status("MyAccountID", true, false)
+	if len(got_values) != 6 {
+		t.Errorf("Status() failed: expected the cache to be invalidated, got %d requests", len(got_values))
+	}
+}
@@ -2,7 +2,10 @@ package golatch
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestNewLatch(t *testing.T) {
@@ -20,3 +23,109 @@ func TestGetLatchURL(t *testing.T) {
 		t.Errorf("GetLatchURL() failed: expected %q, got %q", expected_url, got_url)
 	}
 }
+
+func TestLatchAPIGetLatchURL(t *testing.T) {
+	api := &LatchAPI{}
+	if got_url, err := api.GetLatchURL("status/MyAccountID"); err != nil || got_url.String() != "https://latch.elevenpaths.com/api/1.0/status/MyAccountID" {
+		t.Errorf("LatchAPI.GetLatchURL() failed: expected default endpoint, got %q (error %v)", got_url, err)
+	}
+
+	if err := api.SetAPIURL("http://localhost:8080/gateway/"); err != nil {
+		t.Errorf("SetAPIURL() failed: unexpected error %v", err)
+	}
+	api.SetAPIPath("/latch/")
+	api.SetAPIVersion("1.1")
+	expected_url := "http://localhost:8080/gateway/latch/1.1/status/MyAccountID"
+	if got_url, err := api.GetLatchURL("status/MyAccountID"); err != nil || got_url.String() != expected_url {
+		t.Errorf("LatchAPI.GetLatchURL() failed: expected %q, got %q (error %v)", expected_url, got_url, err)
+	}
+
+	api.SetAPIPath("/")
+	expected_url = "http://localhost:8080/gateway/1.1/status/MyAccountID"
+	if got_url, err := api.GetLatchURL("status/MyAccountID"); err != nil || got_url.String() != expected_url {
+		t.Errorf("LatchAPI.GetLatchURL() failed: expected %q, got %q (error %v)", expected_url, got_url, err)
+	}
+}
+
+func TestSetAPIURLValidation(t *testing.T) {
+	for _, api_url := range []string{"", "latch.elevenpaths.com", "ftp://latch.elevenpaths.com", "https://", "https://latch.elevenpaths.com/?a=b", "http://[::1"} {
+		api := &LatchAPI{}
+		if err := api.SetAPIURL(api_url); err == nil {
+			t.Errorf("SetAPIURL() failed: expected error for %q", api_url)
+		}
+		if api.APIURL != "" {
+			t.Errorf("SetAPIURL() failed: invalid URL %q should not be stored", api_url)
+		}
+	}
+
+	api := &LatchAPI{APIURL: "not a url"}
+	if _, err := api.GetLatchURL("status/MyAccountID"); err == nil {
+		t.Errorf("LatchAPI.GetLatchURL() failed: expected error for invalid APIURL field")
+	}
+}
+
+func TestLatchUsesConfiguredEndpoint(t *testing.T) {
+	var got_path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got_path = r.URL.Path
+		w.Write([]byte(`{"data":{"accountId":"MyAccountID"}}`))
+	}))
+	defer server.Close()
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	if err := latch.SetAPIURL(server.URL); err != nil {
+		t.Fatalf("SetAPIURL() failed: %v", err)
+	}
+
+	if response, err := latch.Pair("my_token"); err != nil || response.AccountId() != "MyAccountID" {
+		t.Errorf("Pair() failed: expected account ID %q, got %v (error %v)", "MyAccountID", response, err)
+	}
+	if got_path != "/api/1.0/pair/my_token" {
+		t.Errorf("Pair() failed: expected request to %q, got %q", "/api/1.0/pair/my_token", got_path)
+	}
+}
+
+func TestPairWithId(t *testing.T) {
+	var got_request *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got_request = r
+		w.Write([]byte(`{"data":{"accountId":"MyAccountID"}}`))
+	}))
+	defer server.Close()
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+
+	response, err := latch.PairWithId("my_account@example.com")
+	if err != nil || response.AccountId() != "MyAccountID" {
+		t.Fatalf("PairWithId() failed: expected account ID %q, got %v (error %v)", "MyAccountID", response, err)
+	}
+
+	expected_path := "/api/1.0/pairWithId/my_account@example.com"
+	if got_request.Method != HTTP_METHOD_GET || got_request.URL.Path != expected_path {
+		t.Errorf("PairWithId() failed: expected GET %q, got %s %q", expected_path, got_request.Method, got_request.URL.Path)
+	}
+
+	//Rebuild the request from the received date to check the signature
+	date, _ := time.Parse(API_UTC_STRING_FORMAT, got_request.Header.Get(API_DATE_HEADER_NAME))
+	request_url, _ := latch.GetLatchURL(fmt.Sprint(API_PAIR_WITH_ID_ACTION, "/", "my_account@example.com"))
+	expected_header := NewLatchRequest("MyAppID", "MySecretKey", HTTP_METHOD_GET, request_url, nil, nil, date).GetAuthorizationHeader()
+	if got_header := got_request.Header.Get(API_AUTHORIZATION_HEADER_NAME); got_header != expected_header {
+		t.Errorf("PairWithId() failed: expected Authorization header %q, got %q", expected_header, got_header)
+	}
+}
+
+func TestPairWithIdError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"error":{"code":205,"message":"Account and application already paired"}}`))
+	}))
+	defer server.Close()
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+
+	response, err := latch.PairWithId("my_account@example.com")
+	if latch_error, ok := err.(*LatchError); !ok || latch_error.Code != 205 || response != nil {
+		t.Errorf("PairWithId() failed: expected Latch error 205 and no response, got %v (error %v)", response, err)
+	}
+}
@@ -1,9 +1,9 @@
 package golatch
 
 import (
+	"context"
 	"fmt"
 	"net/url"
-	t "time"
 )
 
 //Struct to use the Latch User API
@@ -21,10 +21,27 @@ func NewLatchUser(userID string, secretKey string) *LatchUser {
 	}
 }
 
+//Constructs a new LatchUser struct that signs the requests with the signer provided instead of a secret key
+func NewLatchUserWithSigner(userID string, signer Signer) *LatchUser {
+	latch := &LatchUser{UserID: userID}
+	latch.SetSigner(signer)
+	return latch
+}
+
+//Performs a request against the API signed with the user's credentials
+func (l *LatchUser) doRequest(ctx context.Context, httpMethod string, query string, params url.Values, responseType LatchResponse) (*LatchResponse, error) {
+	return l.doSignedRequest(ctx, l.UserID, l.SecretKey, httpMethod, query, params, responseType)
+}
+
 //Gets the user's subscription information
 func (l *LatchUser) Subscription() (response *LatchSubscriptionResponse, err error) {
+	return l.SubscriptionWithContext(context.Background())
+}
+
+//Same as Subscription() but using a context that can cancel the request or set a deadline for it
+func (l *LatchUser) SubscriptionWithContext(ctx context.Context) (response *LatchSubscriptionResponse, err error) {
 	var resp *LatchResponse
-	if resp, err = l.DoRequest(NewLatchRequest(l.UserID, l.SecretKey, HTTP_METHOD_GET, GetLatchURL(API_SUBSCRIPTION_ACTION), nil, nil, t.Now()), &LatchSubscriptionResponse{}); err == nil {
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_GET, API_SUBSCRIPTION_ACTION, nil, &LatchSubscriptionResponse{}); err == nil {
 		response = (*resp).(*LatchSubscriptionResponse)
 	}
 
@@ -33,8 +50,13 @@ func (l *LatchUser) Subscription() (response *LatchSubscriptionResponse, err err
 
 //Shows existing applications
 func (l *LatchUser) ShowApplications() (response *LatchShowApplicationsResponse, err error) {
+	return l.ShowApplicationsWithContext(context.Background())
+}
+
+//Same as ShowApplications() but using a context that can cancel the request or set a deadline for it
+func (l *LatchUser) ShowApplicationsWithContext(ctx context.Context) (response *LatchShowApplicationsResponse, err error) {
 	var resp *LatchResponse
-	if resp, err = l.DoRequest(NewLatchRequest(l.UserID, l.SecretKey, HTTP_METHOD_GET, GetLatchURL(API_APPLICATION_ACTION), nil, nil, t.Now()), &LatchShowApplicationsResponse{}); err == nil {
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_GET, API_APPLICATION_ACTION, nil, &LatchShowApplicationsResponse{}); err == nil {
 		response = (*resp).(*LatchShowApplicationsResponse)
 	}
 	return response, err
@@ -42,11 +64,16 @@ func (l *LatchUser) ShowApplications() (response *LatchShowApplicationsResponse,
 
 //Adds a new application
 func (l *LatchUser) AddApplication(applicationInfo *LatchApplicationInfo) (response *LatchAddApplicationResponse, err error) {
+	return l.AddApplicationWithContext(context.Background(), applicationInfo)
+}
+
+//Same as AddApplication() but using a context that can cancel the request or set a deadline for it
+func (l *LatchUser) AddApplicationWithContext(ctx context.Context, applicationInfo *LatchApplicationInfo) (response *LatchAddApplicationResponse, err error) {
 	var resp *LatchResponse
 
 	params := prepareApplicationParams(applicationInfo)
 
-	if resp, err = l.DoRequest(NewLatchRequest(l.UserID, l.SecretKey, HTTP_METHOD_PUT, GetLatchURL(API_APPLICATION_ACTION), nil, *params, t.Now()), &LatchAddApplicationResponse{}); err == nil {
+	if resp, err = l.doRequest(ctx, HTTP_METHOD_PUT, API_APPLICATION_ACTION, *params, &LatchAddApplicationResponse{}); err == nil {
 		response = (*resp).(*LatchAddApplicationResponse)
 	}
 	return response, err
@@ -54,16 +81,32 @@ func (l *LatchUser) AddApplication(applicationInfo *LatchApplicationInfo) (respo
 
 //Updates application information
 func (l *LatchUser) UpdateApplication(appID string, applicationInfo *LatchApplicationInfo) (err error) {
+	return l.UpdateApplicationWithContext(context.Background(), appID, applicationInfo)
+}
+
+//Same as UpdateApplication() but using a context that can cancel the request or set a deadline for it
+func (l *LatchUser) UpdateApplicationWithContext(ctx context.Context, appID string, applicationInfo *LatchApplicationInfo) (err error) {
 	params := prepareApplicationParams(applicationInfo)
+	//Like in UpdateOperation(), the two factor and lock on request options are left as they are when NOT_SET
+	for _, key := range []string{"two_factor", "lock_on_request"} {
+		if params.Get(key) == NOT_SET {
+			params.Del(key)
+		}
+	}
 
-	_, err = l.DoRequest(NewLatchRequest(l.UserID, l.SecretKey, HTTP_METHOD_POST, GetLatchURL(fmt.Sprint(API_APPLICATION_ACTION, "/", appID)), nil, *params, t.Now()), nil)
+	_, err = l.doRequest(ctx, HTTP_METHOD_POST, fmt.Sprint(API_APPLICATION_ACTION, "/", appID), *params, nil)
 
 	return err
 }
 
 //Deletes an existing application
 func (l *LatchUser) DeleteApplication(applicationId string) (err error) {
-	_, err = l.DoRequest(NewLatchRequest(l.UserID, l.SecretKey, HTTP_METHOD_DELETE, GetLatchURL(fmt.Sprint(API_APPLICATION_ACTION, "/", applicationId)), nil, nil, t.Now()), nil)
+	return l.DeleteApplicationWithContext(context.Background(), applicationId)
+}
+
+//Same as DeleteApplication() but using a context that can cancel the request or set a deadline for it
+func (l *LatchUser) DeleteApplicationWithContext(ctx context.Context, applicationId string) (err error) {
+	_, err = l.doRequest(ctx, HTTP_METHOD_DELETE, fmt.Sprint(API_APPLICATION_ACTION, "/", applicationId), nil, nil)
 	return err
 }
 
@@ -0,0 +1,30 @@
+package golatch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestUpdateApplication(t *testing.T) {
+	var got_form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		got_form = r.PostForm
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	latch := NewLatchUser("MyUserID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+
+	//Empty names and contact details are sent, but not the options that are NOT_SET
+	if err := latch.UpdateApplication("MyAppID", &LatchApplicationInfo{Name: "My Application", TwoFactor: MANDATORY}); err != nil {
+		t.Fatalf("UpdateApplication() failed: unexpected error %v", err)
+	}
+	expected := url.Values{"name": {"My Application"}, "contactEmail": {""}, "contactPhone": {""}, "two_factor": {MANDATORY}}
+	if got_form.Encode() != expected.Encode() {
+		t.Errorf("UpdateApplication() failed: expected params %v, got %v", expected, got_form)
+	}
+}
@@ -0,0 +1,80 @@
+package golatch
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//Gets the secret key of an application (or user) ID. Returns false if the ID is unknown
+type SecretLookup func(id string) (secretKey string, ok bool)
+
+//Verifies the signature of requests signed with the 11PATHS authentication scheme (like the ones sent by this package)
+type LatchVerifier struct {
+	Secret SecretLookup
+	//Max difference allowed between the date of the request (X-11Paths-Date) and the current time (0 disables the check)
+	MaxSkew time.Duration
+	//Clock used to get the current time (nil means the system clock)
+	Clock Clock
+}
+
+//Returns a new verifier that gets the secret keys using lookup and allows dates to differ up to maxSkew from the current time
+func NewLatchVerifier(lookup SecretLookup, maxSkew time.Duration) *LatchVerifier {
+	return &LatchVerifier{Secret: lookup, MaxSkew: maxSkew}
+}
+
+//Verifies an incoming request, returning the application (or user) ID that signed it
+//Returns ErrInvalidAuthorizationHeader if the headers are missing or malformed, ErrAuthorizationExpired if the date is out of the allowed window
+//and ErrInvalidSignature if the ID is unknown or the signature doesn't match
+//The form of POST and PUT requests is parsed (r.ParseForm()) to get the signed parameters
+func (v *LatchVerifier) Verify(r *http.Request) (id string, err error) {
+	fields := strings.Split(r.Header.Get(API_AUTHORIZATION_HEADER_NAME), API_AUTHORIZATION_HEADER_FIELD_SEPARATOR)
+	if len(fields) != 3 || fields[0] != API_AUTHENTICATION_METHOD || fields[1] == "" {
+		return "", ErrInvalidAuthorizationHeader
+	}
+	id = fields[1]
+
+	date, err := time.Parse(API_UTC_STRING_FORMAT, r.Header.Get(API_DATE_HEADER_NAME))
+	if err != nil {
+		return id, ErrInvalidAuthorizationHeader
+	}
+	if v.MaxSkew > 0 {
+		clock := v.Clock
+		if clock == nil {
+			clock = SystemClock
+		}
+		if skew := clock.Now().Sub(date); skew > v.MaxSkew || skew < -v.MaxSkew {
+			return id, ErrAuthorizationExpired
+		}
+	}
+
+	secretKey, ok := v.Secret(id)
+	if !ok {
+		return id, ErrInvalidSignature
+	}
+	if err = r.ParseForm(); err != nil {
+		return id, ErrInvalidSignature
+	}
+
+	request := NewLatchRequest(id, secretKey, r.Method, r.URL, GetXHeaders(r.Header), r.PostForm, date)
+	if subtle.ConstantTimeCompare([]byte(request.GetSignedRequestSignature()), []byte(fields[2])) != 1 {
+		return id, ErrInvalidSignature
+	}
+
+	return id, nil
+}
+
+//Gets the X-11Paths headers (except the date) included in the signature of a request (nil if there are none)
+func GetXHeaders(header http.Header) (xHeaders map[string]string) {
+	prefix := strings.ToLower(API_X_11PATHS_HEADER_PREFIX)
+	for name := range header {
+		if strings.HasPrefix(strings.ToLower(name), prefix) && !strings.EqualFold(name, API_DATE_HEADER_NAME) {
+			if xHeaders == nil {
+				xHeaders = make(map[string]string)
+			}
+			xHeaders[name] = header.Get(name)
+		}
+	}
+	return xHeaders
+}
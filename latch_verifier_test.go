@@ -0,0 +1,75 @@
+package golatch
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func testSecretLookup(id string) (string, bool) {
+	return "MySecretKey", id == "MyAppID"
+}
+
+//Builds an incoming request from a signed LatchRequest
+func newIncomingRequest(request *LatchRequest) *http.Request {
+	r := request.GetHttpRequest()
+	for name, value := range request.XHeaders {
+		r.Header.Set(name, value)
+	}
+	return r
+}
+
+func TestLatchVerifierVerify(t *testing.T) {
+	verifier := NewLatchVerifier(testSecretLookup, 0)
+
+	//The example request is a POST with parameters and X-11Paths headers
+	request := *example_request
+	if id, err := verifier.Verify(newIncomingRequest(&request)); err != nil || id != "MyAppID" {
+		t.Errorf("Verify() failed: expected valid signature from %q, got %q (error %v)", "MyAppID", id, err)
+	}
+
+	tampered := newIncomingRequest(&request)
+	tampered.Header.Set("X-11Paths-B", "Other value")
+	if _, err := verifier.Verify(tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() failed: expected ErrInvalidSignature for a tampered header, got %v", err)
+	}
+
+	request.Params = url.Values{"A": {"Other"}}
+	tampered = newIncomingRequest(&request)
+	request.Params = example_request.Params
+	tampered.Header.Set(API_AUTHORIZATION_HEADER_NAME, request.GetAuthorizationHeader())
+	if _, err := verifier.Verify(tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() failed: expected ErrInvalidSignature for tampered parameters, got %v", err)
+	}
+
+	unknown := request
+	unknown.AppID = "OtherAppID"
+	if _, err := verifier.Verify(newIncomingRequest(&unknown)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() failed: expected ErrInvalidSignature for an unknown ID, got %v", err)
+	}
+
+	missing := newIncomingRequest(&request)
+	missing.Header.Del(API_AUTHORIZATION_HEADER_NAME)
+	if _, err := verifier.Verify(missing); !errors.Is(err, ErrInvalidAuthorizationHeader) {
+		t.Errorf("Verify() failed: expected ErrInvalidAuthorizationHeader, got %v", err)
+	}
+}
+
+func TestLatchVerifierMaxSkew(t *testing.T) {
+	verifier := NewLatchVerifier(testSecretLookup, 5*time.Minute)
+	request_url, _ := url.Parse("https://latch.elevenpaths.com/api/1.0/status/MyAccountID")
+
+	request := NewLatchRequest("MyAppID", "MySecretKey", HTTP_METHOD_GET, request_url, nil, nil, time.Now().Add(-2*time.Minute))
+	if _, err := verifier.Verify(newIncomingRequest(request)); err != nil {
+		t.Errorf("Verify() failed: expected date within the window to be valid, got %v", err)
+	}
+
+	for _, date := range []time.Time{time.Now().Add(-10 * time.Minute), time.Now().Add(10 * time.Minute)} {
+		request := NewLatchRequest("MyAppID", "MySecretKey", HTTP_METHOD_GET, request_url, nil, nil, date)
+		if _, err := verifier.Verify(newIncomingRequest(request)); !errors.Is(err, ErrAuthorizationExpired) {
+			t.Errorf("Verify() failed: expected ErrAuthorizationExpired for date %v, got %v", date, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package golatch
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//Versions of the API. API_VERSION (1.0) is the default one
+//Version 3.0 has the same endpoints and response formats as 1.0, plus the TOTP endpoints (API_TOTP_ACTION)
+const (
+	API_VERSION_1_0 = "1.0"
+	API_VERSION_3_0 = "3.0"
+)
+
+//Error returned when calling an action that is not available in the selected version of the API
+var ErrActionNotSupported = errors.New("action not supported by the Latch API version")
+
+//Endpoints of a version of the API
+type APIVersionSpec struct {
+	Version string
+	//Paths of the actions available in this version, by action (for example API_CHECK_STATUS_ACTION => "status")
+	Actions map[string]string
+	//Optional function that decodes the responses of this version, for example to adapt them to the format of 1.0
+	//before calling Unmarshal() (nil means responses are decoded like in 1.0)
+	Decode func(body []byte, responseType LatchResponse) error
+}
+
+//Responses whose format depends on the version of the API
+//If a response type implements this interface, UnmarshalVersion() is used to decode it instead of Unmarshal()
+type LatchVersionedResponse interface {
+	LatchResponse
+	UnmarshalVersion(Json string, version string) error
+}
+
+//Actions available in all the versions of the API
+var apiActions = []string{
+	API_CHECK_STATUS_ACTION,
+	API_PAIR_ACTION,
+	API_PAIR_WITH_ID_ACTION,
+	API_UNPAIR_ACTION,
+	API_LOCK_ACTION,
+	API_UNLOCK_ACTION,
+	API_HISTORY_ACTION,
+	API_OPERATION_ACTION,
+	API_APPLICATION_ACTION,
+	API_SUBSCRIPTION_ACTION,
+	API_INSTANCE_ACTION,
+}
+
+var apiVersions = struct {
+	sync.RWMutex
+	specs map[string]*APIVersionSpec
+}{specs: map[string]*APIVersionSpec{
+	API_VERSION_1_0: newAPIVersionSpec(API_VERSION_1_0, nil),
+	API_VERSION_3_0: newAPIVersionSpec(API_VERSION_3_0, map[string]string{API_TOTP_ACTION: API_TOTP_ACTION}),
+}}
+
+//Returns a spec with the actions available in all the versions plus the ones provided (action => path)
+func newAPIVersionSpec(version string, actions map[string]string) *APIVersionSpec {
+	spec := &APIVersionSpec{Version: version, Actions: make(map[string]string)}
+	for _, action := range apiActions {
+		spec.Actions[action] = action
+	}
+	for action, path := range actions {
+		spec.Actions[action] = path
+	}
+	return spec
+}
+
+//Registers a version of the API (replacing the existing one, if any)
+func RegisterAPIVersion(spec *APIVersionSpec) {
+	apiVersions.Lock()
+	defer apiVersions.Unlock()
+
+	apiVersions.specs[spec.Version] = spec
+}
+
+//Gets a registered version of the API
+func GetAPIVersionSpec(version string) (*APIVersionSpec, bool) {
+	apiVersions.RLock()
+	defer apiVersions.RUnlock()
+
+	spec, ok := apiVersions.specs[version]
+	return spec, ok
+}
+
+//Gets the registered versions of the API (sorted)
+func APIVersions() []string {
+	apiVersions.RLock()
+	defer apiVersions.RUnlock()
+
+	versions := make([]string, 0, len(apiVersions.specs))
+	for version := range apiVersions.specs {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+//Checks if an action is available in a version of the API
+func (s *APIVersionSpec) Supports(action string) bool {
+	_, ok := s.Actions[action]
+	return ok
+}
+
+//Gets the version of the API used by the client (API_VERSION if none has been set)
+func (l *LatchAPI) GetAPIVersion() string {
+	if l.APIVersion == "" {
+		return API_VERSION
+	}
+	return l.APIVersion
+}
+
+//Checks if an action is available in the version of the API used by the client
+//Versions that are not registered are assumed to support all the actions
+func (l *LatchAPI) SupportsAction(action string) bool {
+	spec, ok := GetAPIVersionSpec(l.GetAPIVersion())
+	return !ok || spec.Supports(action)
+}
+
+//Translates the query of a request (like "status/MyAccountID") to the path of its action in the version of the API used by the client
+func (l *LatchAPI) versionQuery(query string) (string, error) {
+	version := l.GetAPIVersion()
+	spec, ok := GetAPIVersionSpec(version)
+	if !ok {
+		return query, nil
+	}
+
+	parts := strings.SplitN(query, "/", 2)
+	path, ok := spec.Actions[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("%w: %s is not available in version %s", ErrActionNotSupported, parts[0], version)
+	}
+	parts[0] = path
+	return strings.Join(parts, "/"), nil
+}
+
+//Decodes a response using the version of the API of the request: with UnmarshalVersion() if the response type implements LatchVersionedResponse,
+//with the Decode function of the version if it has one, and with Unmarshal() otherwise
+func decodeResponse(request *LatchRequest, body []byte, responseType LatchResponse) error {
+	version := request.APIVersion
+	if version == "" {
+		version = API_VERSION
+	}
+
+	if versioned, ok := responseType.(LatchVersionedResponse); ok {
+		return versioned.UnmarshalVersion(string(body), version)
+	}
+	if spec, ok := GetAPIVersionSpec(version); ok && spec.Decode != nil {
+		return spec.Decode(body, responseType)
+	}
+	return responseType.Unmarshal(string(body))
+}
@@ -0,0 +1,143 @@
+package golatch
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type versionedTestResponse struct {
+	Version string
+	Status  string
+}
+
+func (r *versionedTestResponse) Unmarshal(Json string) error {
+	return errors.New("Unmarshal() should not be called for versioned responses")
+}
+
+func (r *versionedTestResponse) UnmarshalVersion(Json string, version string) error {
+	var data struct {
+		Data struct {
+			Status string `json:"status"`
+			State  string `json:"state"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(Json), &data); err != nil {
+		return err
+	}
+
+	r.Version, r.Status = version, data.Data.Status
+	if version == "9.9" {
+		r.Status = data.Data.State
+	}
+	return nil
+}
+
+func TestAPIVersions(t *testing.T) {
+	var got_paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got_paths = append(got_paths, r.URL.Path)
+		w.Write([]byte(`{"data":{"status":"on","state":"off"}}`))
+	}))
+	defer server.Close()
+
+	spec := newAPIVersionSpec("9.9", map[string]string{API_CHECK_STATUS_ACTION: "checkStatus"})
+	delete(spec.Actions, API_HISTORY_ACTION)
+	RegisterAPIVersion(spec)
+	defer delete(apiVersions.specs, "9.9")
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+	if latch.GetAPIVersion() != API_VERSION_1_0 {
+		t.Errorf("GetAPIVersion() failed: expected default version %s, got %s", API_VERSION_1_0, latch.GetAPIVersion())
+	}
+
+	expected_paths := []string{"/api/1.0/status/MyAccountID", "/api/3.0/status/MyAccountID", "/api/9.9/checkStatus/MyAccountID", "/api/1.1/status/MyAccountID"}
+	for i, version := range []string{"", API_VERSION_3_0, "9.9", "1.1"} {
+		latch.SetAPIVersion(version)
+
+		request_url, _ := latch.GetLatchURL("")
+		request := NewLatchRequest("MyAppID", "MySecretKey", HTTP_METHOD_GET, request_url, nil, nil, latch.Now())
+		if _, err := latch.Status("MyAccountID", false, false); err != nil {
+			t.Errorf("Status() failed: unexpected error %v for version %q", err, version)
+		}
+		if got_paths[len(got_paths)-1] != expected_paths[i] {
+			t.Errorf("Status() failed: expected path %q for version %q, got %q", expected_paths[i], version, got_paths[len(got_paths)-1])
+		}
+
+		//Versioned responses
+		request.APIVersion = latch.GetAPIVersion()
+		resp, err := latch.DoRequest(request, &versionedTestResponse{})
+		if err != nil {
+			t.Fatalf("DoRequest() failed: unexpected error %v", err)
+		}
+		response := (*resp).(*versionedTestResponse)
+		expected_status := LATCH_STATUS_ON
+		if version == "9.9" {
+			expected_status = LATCH_STATUS_OFF
+		}
+		if response.Version != latch.GetAPIVersion() || response.Status != expected_status {
+			t.Errorf("DoRequest() failed: expected response decoded for version %s, got %+v", latch.GetAPIVersion(), response)
+		}
+	}
+
+	//Actions not available in the selected version
+	latch.SetAPIVersion("9.9")
+	requests := len(got_paths)
+	if latch.SupportsAction(API_HISTORY_ACTION) || !latch.SupportsAction(API_CHECK_STATUS_ACTION) {
+		t.Errorf("SupportsAction() failed: unexpected actions for version 9.9")
+	}
+	if _, err := latch.History("MyAccountID", example_date, example_date); !errors.Is(err, ErrActionNotSupported) {
+		t.Errorf("History() failed: expected ErrActionNotSupported, got %v", err)
+	}
+	if len(got_paths) != requests {
+		t.Errorf("History() failed: expected request not to be sent")
+	}
+	//TOTP is the only difference between 1.0 and 3.0
+	latch.SetAPIVersion(API_VERSION_1_0)
+	if latch.SupportsAction(API_TOTP_ACTION) {
+		t.Errorf("SupportsAction() failed: expected TOTP not to be available in version %s", API_VERSION_1_0)
+	}
+	latch.SetAPIVersion(API_VERSION_3_0)
+	if !latch.SupportsAction(API_TOTP_ACTION) {
+		t.Errorf("SupportsAction() failed: expected TOTP to be available in version %s", API_VERSION_3_0)
+	}
+	latch.SetAPIVersion("1.1")
+	if !latch.SupportsAction(API_HISTORY_ACTION) {
+		t.Errorf("SupportsAction() failed: expected versions that are not registered to support all the actions")
+	}
+
+	if versions := APIVersions(); len(versions) != 3 || versions[0] != API_VERSION_1_0 || versions[1] != API_VERSION_3_0 {
+		t.Errorf("APIVersions() failed: unexpected versions %v", versions)
+	}
+}
+
+func TestAPIVersionDecode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"operations":{"MyAppID":{"state":"off"}}}}`))
+	}))
+	defer server.Close()
+
+	//A version that renames the status field of the responses
+	spec := newAPIVersionSpec("9.8", nil)
+	spec.Decode = func(body []byte, responseType LatchResponse) error {
+		return responseType.Unmarshal(strings.Replace(string(body), `"state"`, `"status"`, -1))
+	}
+	RegisterAPIVersion(spec)
+	defer delete(apiVersions.specs, "9.8")
+
+	latch := NewLatch("MyAppID", "MySecretKey")
+	latch.SetAPIURL(server.URL)
+	latch.SetAPIVersion("9.8")
+	if response, err := latch.Status("MyAccountID", false, false); err != nil || response.Status() != LATCH_STATUS_OFF {
+		t.Errorf("Status() failed: expected status decoded by the version, got %v (error %v)", response, err)
+	}
+
+	latch.SetAPIVersion(API_VERSION_1_0)
+	if response, err := latch.Status("MyAccountID", false, false); err != nil || response.Status() != "" {
+		t.Errorf("Status() failed: expected version 1.0 to ignore the decoder of other versions, got %v (error %v)", response, err)
+	}
+}
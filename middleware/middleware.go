@@ -0,0 +1,137 @@
+//Package middleware provides net/http middleware that gates handlers on the status of a latch
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/millenc/golatch"
+)
+
+//Resolves the Latch account ID of the user making the request (empty if there's none, for example anonymous users)
+type AccountResolver func(r *http.Request) string
+
+type contextKey int
+
+const decisionKey contextKey = 0
+
+//Middleware that checks the status of the latch of the user's account (or of the operation mapped to the request) before calling the wrapped handler
+type Middleware struct {
+	Guard     *golatch.Guard
+	AccountID AccountResolver
+	//Handler called when the access is denied (by default it answers with 403 Forbidden)
+	DenyHandler http.Handler
+	//Let requests through when the verdict is golatch.VerdictUnknown (for example when the account is not paired)
+	AllowUnknown bool
+
+	routes []route
+}
+
+//Operation mapped to a method (optional) and path
+type route struct {
+	method      string
+	path        string
+	operationId string
+}
+
+//Returns a new middleware that uses the guard provided to check the account returned by accountID
+//operations maps routes to operation IDs. Routes have the form "[METHOD ]/path": paths ending in a slash match all the paths below them,
+//otherwise the path must match exactly. The longest path wins. An empty operation ID means the status of the application
+//Requests that don't match any route are not checked. If operations is nil the status of the application is checked for all the requests
+func New(guard *golatch.Guard, accountID AccountResolver, operations map[string]string) (*Middleware, error) {
+	m := &Middleware{Guard: guard, AccountID: accountID}
+	if operations == nil {
+		return m, nil
+	}
+
+	m.routes = make([]route, 0, len(operations))
+	for pattern, operationId := range operations {
+		r := route{path: pattern, operationId: operationId}
+		if i := strings.Index(pattern, " "); i >= 0 {
+			r.method, r.path = pattern[:i], strings.TrimSpace(pattern[i+1:])
+		}
+		if !strings.HasPrefix(r.path, "/") {
+			return nil, fmt.Errorf("invalid route %q: path must start with /", pattern)
+		}
+		m.routes = append(m.routes, r)
+	}
+
+	//Longest paths first, routes with a method before routes without one
+	sort.Slice(m.routes, func(i, j int) bool {
+		if len(m.routes[i].path) != len(m.routes[j].path) {
+			return len(m.routes[i].path) > len(m.routes[j].path)
+		}
+		return m.routes[i].method > m.routes[j].method
+	})
+
+	return m, nil
+}
+
+//Wraps a handler so it is only called when the latch is on
+//The decision is stored in the request's context (use FromContext() to get it)
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operationId, ok := m.operation(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		accountId := m.AccountID(r)
+		if accountId == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var decision golatch.Decision
+		if operationId == "" {
+			decision = m.Guard.Check(r.Context(), accountId)
+		} else {
+			decision = m.Guard.CheckOperation(r.Context(), accountId, operationId)
+		}
+		r = r.WithContext(NewContext(r.Context(), decision))
+
+		if decision.Allowed() || (decision.Verdict == golatch.VerdictUnknown && m.AllowUnknown) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if m.DenyHandler != nil {
+			m.DenyHandler.ServeHTTP(w, r)
+		} else {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		}
+	})
+}
+
+//Gets the operation ID mapped to a request. Returns false if the request must not be checked
+func (m *Middleware) operation(r *http.Request) (string, bool) {
+	if m.routes == nil {
+		return "", true
+	}
+
+	for _, route := range m.routes {
+		if route.method != "" && route.method != r.Method {
+			continue
+		}
+		if r.URL.Path == route.path || (strings.HasSuffix(route.path, "/") && strings.HasPrefix(r.URL.Path, route.path)) {
+			return route.operationId, true
+		}
+	}
+
+	return "", false
+}
+
+//Returns a copy of the context with the decision provided
+func NewContext(ctx context.Context, decision golatch.Decision) context.Context {
+	return context.WithValue(ctx, decisionKey, decision)
+}
+
+//Gets the decision stored in a context by the middleware (the status response is available in decision.Response)
+func FromContext(ctx context.Context) (decision golatch.Decision, ok bool) {
+	decision, ok = ctx.Value(decisionKey).(golatch.Decision)
+	return decision, ok
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/millenc/golatch"
+	"github.com/millenc/golatch/golatchtest"
+)
+
+//Fake Latch API with MyAccountID paired: the status of MyOperationID is off, everything else is on
+func newTestServer() *golatchtest.Server {
+	server := golatchtest.NewServer()
+	server.AddApplication("", "MyAppID", "MySecretKey", "My Application")
+	server.AddOperation("MyAppID", "MyAppID", "MyOperationID", "My Operation")
+	server.PairAccount("MyAppID", "MyAccountID")
+	server.SetOperationStatus("MyAppID", "MyAccountID", "MyOperationID", golatch.LATCH_STATUS_OFF)
+	return server
+}
+
+func newTestMiddleware(t *testing.T, server *golatchtest.Server, policy golatch.FailurePolicy, operations map[string]string) *Middleware {
+	m, err := New(golatch.NewGuard(server.Latch("MyAppID"), policy), func(r *http.Request) string {
+		return r.Header.Get("X-Account")
+	}, operations)
+	if err != nil {
+		t.Fatalf("New() failed: unexpected error %v", err)
+	}
+	return m
+}
+
+//Handler that answers with the status stored in the context by the middleware
+var statusHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if decision, ok := FromContext(r.Context()); ok {
+		w.Write([]byte(decision.Response.Status()))
+	}
+})
+
+func serve(handler http.Handler, method string, path string, account string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, nil)
+	if account != "" {
+		request.Header.Set("X-Account", account)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	handler := newTestMiddleware(t, server, golatch.FailClosed, map[string]string{
+		"POST /transfers": "MyOperationID",
+		"GET /account":    "",
+	}).Wrap(statusHandler)
+
+	tests := []struct {
+		method  string
+		path    string
+		account string
+		code    int
+		body    string
+		checks  int
+	}{
+		{"GET", "/account", "MyAccountID", http.StatusOK, golatch.LATCH_STATUS_ON, 1},
+		{"POST", "/transfers", "MyAccountID", http.StatusForbidden, "Forbidden\n", 2},
+		{"GET", "/transfers", "MyAccountID", http.StatusOK, "", 2},
+		{"POST", "/transfers", "", http.StatusOK, "", 2},
+	}
+
+	for _, test := range tests {
+		recorder := serve(handler, test.method, test.path, test.account)
+		if checks := len(server.CallsTo(golatch.API_CHECK_STATUS_ACTION)); recorder.Code != test.code || recorder.Body.String() != test.body || checks != test.checks {
+			t.Errorf("Wrap() failed for %s %s: expected %d %q after %d checks, got %d %q after %d checks", test.method, test.path, test.code, test.body, test.checks, recorder.Code, recorder.Body.String(), checks)
+		}
+	}
+
+	if path := server.CallsTo(golatch.API_CHECK_STATUS_ACTION)[1].Path; path != "/api/1.0/status/MyAccountID/op/MyOperationID" {
+		t.Errorf("Wrap() failed: expected operation status request, got %q", path)
+	}
+}
+
+func TestMiddlewareDenyHandler(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	m := newTestMiddleware(t, server, golatch.FailClosed, map[string]string{"/": "MyOperationID"})
+	m.DenyHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decision, _ := FromContext(r.Context())
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(decision.Reason))
+	})
+
+	recorder := serve(m.Wrap(statusHandler), "GET", "/anything", "MyAccountID")
+	if recorder.Code != http.StatusUnauthorized || recorder.Body.String() != golatch.REASON_STATUS_OFF {
+		t.Errorf("Wrap() failed: expected custom deny response, got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestMiddlewareTwoFactor(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	server.AddOperation("MyAppID", "MyAppID", "MyTwoFactorOperationID", "My Two Factor Operation").TwoFactor = golatch.MANDATORY
+
+	m := newTestMiddleware(t, server, golatch.FailOpen, map[string]string{"/": "MyTwoFactorOperationID"})
+	var token string
+	m.DenyHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decision, _ := FromContext(r.Context())
+		token = decision.Response.TwoFactor().Token
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(decision.Reason))
+	})
+
+	//The operation is on, but the one time password sent to the user must be verified before letting the request through
+	recorder := serve(m.Wrap(statusHandler), "GET", "/transfers", "MyAccountID")
+	if recorder.Code != http.StatusUnauthorized || recorder.Body.String() != golatch.REASON_TWO_FACTOR_REQUIRED || token == "" {
+		t.Errorf("Wrap() failed: expected two factor to be required, got %d %q (token %q)", recorder.Code, recorder.Body.String(), token)
+	}
+}
+
+func TestMiddlewareAllRequests(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	recorder := serve(newTestMiddleware(t, server, golatch.FailClosed, nil).Wrap(statusHandler), "DELETE", "/whatever", "MyAccountID")
+	if recorder.Code != http.StatusOK || recorder.Body.String() != golatch.LATCH_STATUS_ON || len(server.CallsTo(golatch.API_CHECK_STATUS_ACTION)) != 1 {
+		t.Errorf("Wrap() failed: expected application status check, got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	if _, err := New(nil, nil, map[string]string{"GET account": "MyOperationID"}); err == nil {
+		t.Errorf("New() failed: expected error for invalid pattern")
+	}
+}
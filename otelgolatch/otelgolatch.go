@@ -0,0 +1,242 @@
+//Package otelgolatch instruments the requests sent to the Latch API with OpenTelemetry traces and metrics
+package otelgolatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/millenc/golatch"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//Name of the instrumentation scope of the tracer and the meter
+const INSTRUMENTATION_NAME = "github.com/millenc/golatch/otelgolatch"
+
+//Attribute keys
+const (
+	ACTION_KEY           = attribute.Key("latch.action")
+	LATCH_ERROR_CODE_KEY = attribute.Key("latch.error.code")
+	HTTP_METHOD_KEY      = attribute.Key("http.request.method")
+	STATUS_CODE_KEY      = attribute.Key("http.response.status_code")
+	URL_PATH_KEY         = attribute.Key("url.path")
+	ERROR_TYPE_KEY       = attribute.Key("error.type")
+	ATTEMPT_KEY          = attribute.Key("latch.attempt")
+)
+
+//Values of the error.type attribute
+const (
+	ERROR_TYPE_LATCH     = "latch_error"
+	ERROR_TYPE_HTTP      = "http_error"
+	ERROR_TYPE_TRANSPORT = "transport_error"
+	ERROR_TYPE_SIGNING   = "signing_error"
+)
+
+//Metric names
+const (
+	REQUESTS_METRIC = "latch.client.requests"
+	ERRORS_METRIC   = "latch.client.errors"
+	DURATION_METRIC = "latch.client.duration"
+	ATTEMPTS_METRIC = "latch.client.attempts"
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+}
+
+//Configures the observer
+type Option func(*config)
+
+//Sets the tracer provider used to create the spans (the global one by default)
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+//Sets the meter provider used to record the metrics (the global one by default)
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+//Sets the propagator used to inject the trace context in the requests (the global one by default)
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+//Implementation of golatch.RequestObserver that creates a client span for every request (with a child span for each attempt)
+//and records its metrics
+type Observer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	requests   metric.Int64Counter
+	errors     metric.Int64Counter
+	duration   metric.Float64Histogram
+	attempts   metric.Int64Counter
+}
+
+type startKey struct{}
+
+//Returns a new observer
+func NewObserver(options ...Option) (*Observer, error) {
+	c := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagator:     otel.GetTextMapPropagator(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	meter := c.meterProvider.Meter(INSTRUMENTATION_NAME)
+	o := &Observer{
+		tracer:     c.tracerProvider.Tracer(INSTRUMENTATION_NAME),
+		propagator: c.propagator,
+	}
+
+	var err error
+	if o.requests, err = meter.Int64Counter(REQUESTS_METRIC, metric.WithDescription("Number of requests sent to the Latch API"), metric.WithUnit("{request}")); err != nil {
+		return nil, err
+	}
+	if o.errors, err = meter.Int64Counter(ERRORS_METRIC, metric.WithDescription("Number of requests to the Latch API that failed"), metric.WithUnit("{request}")); err != nil {
+		return nil, err
+	}
+	if o.duration, err = meter.Float64Histogram(DURATION_METRIC, metric.WithDescription("Duration of the requests sent to the Latch API"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if o.attempts, err = meter.Int64Counter(ATTEMPTS_METRIC, metric.WithDescription("Number of attempts of the requests sent to the Latch API (including retries)"), metric.WithUnit("{attempt}")); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+//Instruments the API client provided (a golatch.Latch or golatch.LatchUser can be passed using &latch.LatchAPI)
+func Instrument(api *golatch.LatchAPI, options ...Option) error {
+	observer, err := NewObserver(options...)
+	if err != nil {
+		return err
+	}
+	api.SetObserver(observer)
+	return nil
+}
+
+//Starts the span of the request
+func (o *Observer) RequestStart(ctx context.Context, request *golatch.LatchRequest) context.Context {
+	ctx, _ = o.tracer.Start(ctx, spanName(request), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		ACTION_KEY.String(request.Action),
+		HTTP_METHOD_KEY.String(request.HttpMethod),
+		URL_PATH_KEY.String(request.GetRedactedPath()),
+	))
+	return context.WithValue(ctx, startKey{}, time.Now())
+}
+
+//Starts the span of an attempt of the request (a child of the span of the request) and injects the trace context in its headers
+func (o *Observer) AttemptStart(ctx context.Context, request *golatch.LatchRequest, attempt int, httpRequest *http.Request) context.Context {
+	ctx, _ = o.tracer.Start(ctx, fmt.Sprint(spanName(request), " attempt"), trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(
+		ACTION_KEY.String(request.Action),
+		ATTEMPT_KEY.Int(attempt),
+	))
+	o.propagator.Inject(ctx, propagation.HeaderCarrier(httpRequest.Header))
+	return ctx
+}
+
+//Ends the span of an attempt and counts it
+func (o *Observer) AttemptEnd(ctx context.Context, request *golatch.LatchRequest, attempt int, statusCode int, err error) {
+	attrs := resultAttributes(request, statusCode, err)
+	endSpan(trace.SpanFromContext(ctx), request, err, append(attrs, ATTEMPT_KEY.Int(attempt)))
+	o.attempts.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
+
+//Ends the span of the request and records its metrics
+func (o *Observer) RequestEnd(ctx context.Context, request *golatch.LatchRequest, statusCode int, err error) {
+	attrs := resultAttributes(request, statusCode, err)
+	endSpan(trace.SpanFromContext(ctx), request, err, attrs)
+
+	set := metric.WithAttributes(attrs...)
+	o.requests.Add(ctx, 1, set)
+	if err != nil {
+		o.errors.Add(ctx, 1, set)
+	}
+	if start, ok := ctx.Value(startKey{}).(time.Time); ok {
+		o.duration.Record(ctx, time.Since(start).Seconds(), set)
+	}
+}
+
+//Gets the attributes that describe the result of a request or attempt
+func resultAttributes(request *golatch.LatchRequest, statusCode int, err error) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		ACTION_KEY.String(request.Action),
+		HTTP_METHOD_KEY.String(request.HttpMethod),
+	}
+	if statusCode != 0 {
+		attrs = append(attrs, STATUS_CODE_KEY.Int(statusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, errorAttributes(err)...)
+	}
+	return attrs
+}
+
+//Records the error (if any) and the attributes provided in a span and ends it
+func endSpan(span trace.Span, request *golatch.LatchRequest, err error, attrs []attribute.KeyValue) {
+	if err != nil {
+		//Errors of the HTTP client include the URL (and with it the pairing token), so they are redacted like in the logs
+		redacted := &redactedError{err: err, message: request.GetRedactedError(err)}
+		span.RecordError(redacted)
+		span.SetStatus(codes.Error, redacted.message)
+	}
+	span.SetAttributes(attrs...)
+	span.End()
+}
+
+//Error with its message redacted (see golatch.LatchRequest.GetRedactedError())
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+//Gets the name of the span of a request (like "latch status")
+func spanName(request *golatch.LatchRequest) string {
+	if request.Action == "" {
+		return "latch"
+	}
+	return "latch " + request.Action
+}
+
+//Gets the attributes that describe an error
+func errorAttributes(err error) []attribute.KeyValue {
+	var latch_error *golatch.LatchError
+	var http_error *golatch.LatchHttpError
+
+	switch {
+	case errors.As(err, &latch_error):
+		return []attribute.KeyValue{ERROR_TYPE_KEY.String(ERROR_TYPE_LATCH), LATCH_ERROR_CODE_KEY.Int(int(latch_error.Code))}
+	case errors.As(err, &http_error):
+		return []attribute.KeyValue{ERROR_TYPE_KEY.String(ERROR_TYPE_HTTP)}
+	case errors.Is(err, golatch.ErrSigningFailed):
+		return []attribute.KeyValue{ERROR_TYPE_KEY.String(ERROR_TYPE_SIGNING)}
+	}
+	return []attribute.KeyValue{ERROR_TYPE_KEY.String(ERROR_TYPE_TRANSPORT)}
+}
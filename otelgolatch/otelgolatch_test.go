@@ -0,0 +1,297 @@
+package otelgolatch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/millenc/golatch"
+	"github.com/millenc/golatch/golatchtest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type testEnvironment struct {
+	server *golatchtest.Server
+	latch  *golatch.Latch
+	spans  *tracetest.SpanRecorder
+	reader *sdkmetric.ManualReader
+	tracer trace.Tracer
+}
+
+func newTestEnvironment(t *testing.T) *testEnvironment {
+	env := &testEnvironment{
+		server: golatchtest.NewServer(),
+		spans:  tracetest.NewSpanRecorder(),
+		reader: sdkmetric.NewManualReader(),
+	}
+	env.server.AddApplication("", "MyAppID", "MySecretKey", "My Application")
+	env.latch = env.server.Latch("MyAppID")
+
+	tracer_provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(env.spans))
+	env.tracer = tracer_provider.Tracer("test")
+	err := Instrument(&env.latch.LatchAPI,
+		WithTracerProvider(tracer_provider),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(env.reader))),
+		WithPropagator(propagation.TraceContext{}))
+	if err != nil {
+		t.Fatalf("Instrument() failed: unexpected error %v", err)
+	}
+	return env
+}
+
+func (env *testEnvironment) metrics(t *testing.T) map[string]metricdata.Aggregation {
+	var data metricdata.ResourceMetrics
+	if err := env.reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("Collect() failed: unexpected error %v", err)
+	}
+	metrics := map[string]metricdata.Aggregation{}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+//Gets the total of a counter (0 if it hasn't been recorded)
+func sum(data metricdata.Aggregation) (total int64) {
+	if counter, ok := data.(metricdata.Sum[int64]); ok {
+		for _, point := range counter.DataPoints {
+			total += point.Value
+		}
+	}
+	return total
+}
+
+func attributeValue(attrs []attribute.KeyValue, key attribute.Key) (attribute.Value, bool) {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+//Gets the ended spans with the name provided
+func (env *testEnvironment) ended(name string) (spans []sdktrace.ReadOnlySpan) {
+	for _, span := range env.spans.Ended() {
+		if span.Name() == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestSpans(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.server.Close()
+	env.server.AddPairingToken("MyToken")
+
+	ctx, parent := env.tracer.Start(context.Background(), "parent")
+	if _, err := env.latch.PairWithContext(ctx, "MyToken"); err != nil {
+		t.Fatalf("Pair() failed: unexpected error %v", err)
+	}
+	parent.End()
+	if _, err := env.latch.Status("MyUnknownAccount", false, false); err == nil {
+		t.Fatalf("Status() failed: expected error for an unpaired account")
+	}
+
+	if spans := env.spans.Ended(); len(spans) != 5 {
+		t.Fatalf("Expected 5 spans, got %d", len(spans))
+	}
+
+	pairs, pair_attempts := env.ended("latch pair"), env.ended("latch pair attempt")
+	if len(pairs) != 1 || len(pair_attempts) != 1 {
+		t.Fatalf("Expected a span for the pairing and another one for its attempt, got %d and %d", len(pairs), len(pair_attempts))
+	}
+	pair, attempt := pairs[0], pair_attempts[0]
+	if pair.SpanKind() != trace.SpanKindClient {
+		t.Errorf("Expected client span, got kind %v", pair.SpanKind())
+	}
+	if pair.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("Expected span of the request to be a child of the span in the context")
+	}
+	if attempt.Parent().SpanID() != pair.SpanContext().SpanID() {
+		t.Errorf("Expected span of the attempt to be a child of the span of the request")
+	}
+	if value, _ := attributeValue(pair.Attributes(), URL_PATH_KEY); value.AsString() != "/api/1.0/pair/"+golatch.REDACTED {
+		t.Errorf("Expected token to be redacted in the path, got %q", value.AsString())
+	}
+	if value, _ := attributeValue(pair.Attributes(), STATUS_CODE_KEY); value.AsInt64() != 200 {
+		t.Errorf("Expected status code 200, got %v", value.AsInt64())
+	}
+	if value, _ := attributeValue(attempt.Attributes(), ATTEMPT_KEY); value.AsInt64() != 1 {
+		t.Errorf("Expected attempt 1, got %v", value.AsInt64())
+	}
+	call := env.server.CallsTo("pair")[0]
+	propagated := propagation.TraceContext{}.Extract(context.Background(), propagation.HeaderCarrier(call.Header))
+	if trace.SpanContextFromContext(propagated).SpanID() != attempt.SpanContext().SpanID() {
+		t.Errorf("Expected trace context of the attempt to be propagated")
+	}
+
+	statuses := env.ended("latch status")
+	if len(statuses) != 1 || statuses[0].Status().Code != codes.Error {
+		t.Fatalf("Expected a failed span named 'latch status', got %v", statuses)
+	}
+	if value, _ := attributeValue(statuses[0].Attributes(), LATCH_ERROR_CODE_KEY); value.AsInt64() != int64(golatch.ErrAccountNotPaired.Code) {
+		t.Errorf("Expected Latch error code %d, got %v", golatch.ErrAccountNotPaired.Code, value.AsInt64())
+	}
+	if value, _ := attributeValue(statuses[0].Attributes(), ACTION_KEY); value.AsString() != "status" {
+		t.Errorf("Expected action status, got %q", value.AsString())
+	}
+}
+
+//Transport that answers with a 503 error to the first requests
+type failingTransport struct {
+	failures int
+}
+
+func (f *failingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if f.failures > 0 {
+		f.failures--
+		recorder := httptest.NewRecorder()
+		recorder.WriteHeader(http.StatusServiceUnavailable)
+		return recorder.Result(), nil
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestSpansRetries(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.server.Close()
+	env.server.PairAccount("MyAppID", "MyAccountID")
+	env.latch.SetTransport(&failingTransport{failures: 1})
+	env.latch.SetRetryPolicy(&golatch.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+
+	if _, err := env.latch.Status("MyAccountID", false, false); err != nil {
+		t.Fatalf("Status() failed: unexpected error %v", err)
+	}
+
+	statuses, attempts := env.ended("latch status"), env.ended("latch status attempt")
+	if len(statuses) != 1 || len(attempts) != 2 {
+		t.Fatalf("Expected a span for the request and one for each attempt, got %d and %d", len(statuses), len(attempts))
+	}
+	if statuses[0].Status().Code == codes.Error {
+		t.Errorf("Expected the span of the request to succeed, got %v", statuses[0].Status())
+	}
+	if value, _ := attributeValue(attempts[0].Attributes(), STATUS_CODE_KEY); attempts[0].Status().Code != codes.Error || value.AsInt64() != http.StatusServiceUnavailable {
+		t.Errorf("Expected the first attempt to fail with status code 503, got %v (%v)", attempts[0].Status(), value.AsInt64())
+	}
+	for _, attempt := range attempts {
+		if attempt.Parent().SpanID() != statuses[0].SpanContext().SpanID() {
+			t.Errorf("Expected span of the attempt to be a child of the span of the request")
+		}
+	}
+
+	metrics := env.metrics(t)
+	if total := sum(metrics[REQUESTS_METRIC]); total != 1 {
+		t.Errorf("Expected 1 request, got %d", total)
+	}
+	if total := sum(metrics[ATTEMPTS_METRIC]); total != 2 {
+		t.Errorf("Expected 2 attempts, got %d", total)
+	}
+}
+
+type failingSigner struct{}
+
+func (failingSigner) Sign(ctx context.Context, message []byte) ([]byte, error) {
+	return nil, errors.New("signer unavailable")
+}
+
+func TestSpansSigningErrors(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.server.Close()
+	env.latch.SetSigner(failingSigner{})
+
+	if _, err := env.latch.Status("MyAccountID", false, false); !errors.Is(err, golatch.ErrSigningFailed) {
+		t.Fatalf("Status() failed: expected ErrSigningFailed, got %v", err)
+	}
+
+	statuses := env.ended("latch status")
+	if len(statuses) != 1 || statuses[0].Status().Code != codes.Error || len(env.ended("latch status attempt")) != 0 {
+		t.Fatalf("Expected a failed span for the request without attempts, got %v", env.spans.Ended())
+	}
+	if value, _ := attributeValue(statuses[0].Attributes(), ERROR_TYPE_KEY); value.AsString() != ERROR_TYPE_SIGNING {
+		t.Errorf("Expected error type %s, got %q", ERROR_TYPE_SIGNING, value.AsString())
+	}
+	if total := sum(env.metrics(t)[ERRORS_METRIC]); total != 1 {
+		t.Errorf("Expected 1 error to be recorded, got %d", total)
+	}
+}
+
+func TestSpansRedactErrors(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.server.Close()
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+	env.latch.SetAPIURL(closed.URL)
+
+	if _, err := env.latch.Pair("MyPairingToken"); err == nil || !strings.Contains(err.Error(), "MyPairingToken") {
+		t.Fatalf("Pair() failed: expected a transport error with the URL, got %v", err)
+	}
+
+	for _, span := range env.spans.Ended() {
+		if strings.Contains(span.Status().Description, "MyPairingToken") || !strings.Contains(span.Status().Description, "/pair/"+golatch.REDACTED) {
+			t.Errorf("Expected token to be redacted in the status of the span %q, got %q", span.Name(), span.Status().Description)
+		}
+		for _, event := range span.Events() {
+			for _, attr := range event.Attributes {
+				if strings.Contains(attr.Value.Emit(), "MyPairingToken") {
+					t.Errorf("Expected token to be redacted in the events of the span %q, got %s=%q", span.Name(), attr.Key, attr.Value.Emit())
+				}
+			}
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	env := newTestEnvironment(t)
+	defer env.server.Close()
+	env.server.AddPairingToken("MyToken")
+
+	response, err := env.latch.Pair("MyToken")
+	if err != nil {
+		t.Fatalf("Pair() failed: unexpected error %v", err)
+	}
+	env.latch.Status(response.AccountId(), false, false)
+	env.latch.Status("MyUnknownAccount", false, false)
+
+	metrics := env.metrics(t)
+	if total := sum(metrics[REQUESTS_METRIC]); total != 3 {
+		t.Errorf("Expected 3 requests, got %d", total)
+	}
+	if total := sum(metrics[ATTEMPTS_METRIC]); total != 3 {
+		t.Errorf("Expected 3 attempts, got %d", total)
+	}
+
+	errors_metric, ok := metrics[ERRORS_METRIC].(metricdata.Sum[int64])
+	if !ok || len(errors_metric.DataPoints) != 1 || errors_metric.DataPoints[0].Value != 1 {
+		t.Fatalf("Expected 1 error to be recorded, got %v", metrics[ERRORS_METRIC])
+	}
+	if value, _ := errors_metric.DataPoints[0].Attributes.Value(LATCH_ERROR_CODE_KEY); value.AsInt64() != int64(golatch.ErrAccountNotPaired.Code) {
+		t.Errorf("Expected error to be recorded with Latch error code %d, got %v", golatch.ErrAccountNotPaired.Code, value.AsInt64())
+	}
+
+	duration, ok := metrics[DURATION_METRIC].(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatalf("Expected %s to be recorded", DURATION_METRIC)
+	}
+	count := uint64(0)
+	for _, point := range duration.DataPoints {
+		count += point.Count
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 durations, got %d", count)
+	}
+}
@@ -0,0 +1,32 @@
+package unixsigner_test
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/millenc/golatch"
+	"github.com/millenc/golatch/unixsigner"
+)
+
+//The signing daemon holds the secret keys (read from its own configuration)
+func ExampleHandler() {
+	listener, err := unixsigner.Listen("/run/latch-signer/signer.sock")
+	if err != nil {
+		log.Fatal(err)
+	}
+	handler := unixsigner.NewHandler(map[string]string{
+		os.Getenv("LATCH_APP_ID"): os.Getenv("LATCH_SECRET_KEY"),
+	})
+	log.Fatal(http.Serve(listener, handler))
+}
+
+//The application only needs the path of the socket and its application ID
+func ExampleSigner() {
+	appID := "MyAppID"
+	latch := golatch.NewLatchWithSigner(appID, unixsigner.New("/run/latch-signer/signer.sock", appID))
+
+	if status, err := latch.Status("MyAccountID", false, false); err == nil {
+		log.Println(status.Status())
+	}
+}
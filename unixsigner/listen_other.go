@@ -0,0 +1,10 @@
+//go:build !unix
+
+package unixsigner
+
+import "os"
+
+//Ownership can't be checked in a portable way on other systems
+func checkOwner(dir string, info os.FileInfo) error {
+	return nil
+}
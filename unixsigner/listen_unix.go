@@ -0,0 +1,17 @@
+//go:build unix
+
+package unixsigner
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+//Checks that the directory of the socket belongs to the current user
+func checkOwner(dir string, info os.FileInfo) error {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("directory %s doesn't belong to the current user", dir)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package unixsigner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenPrivateDir(t *testing.T) {
+	dir, err := os.MkdirTemp("", "unixsigner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	//The directory of the socket is created if it doesn't exist
+	listener, err := Listen(filepath.Join(dir, "private", "signer.sock"))
+	if err != nil {
+		t.Fatalf("Listen() failed: unexpected error %v", err)
+	}
+	listener.Close()
+	if info, err := os.Stat(filepath.Join(dir, "private")); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("Listen() failed: expected directory with 0700 permissions, got %v (error %v)", info.Mode().Perm(), err)
+	}
+
+	//Directories accessible by other users are rejected
+	os.Chmod(dir, 0755)
+	if listener, err := Listen(filepath.Join(dir, "signer.sock")); err == nil {
+		listener.Close()
+		t.Errorf("Listen() failed: expected error for a directory accessible by other users")
+	}
+}
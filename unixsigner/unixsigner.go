@@ -0,0 +1,161 @@
+//Package unixsigner implements a golatch.Signer that delegates the signing of the requests to a local daemon listening on a Unix socket,
+//so the secret keys live only in the daemon's memory. It also provides the handler of the daemon.
+//
+//The protocol is HTTP over the Unix socket: the client posts the message to sign to /sign?key=<key ID> and the daemon answers
+//with the raw signature (or a non 200 status code and the error in the body).
+package unixsigner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/millenc/golatch"
+)
+
+//Path of the signing endpoint of the daemon
+const SIGN_PATH = "/sign"
+
+//Maximum size of the messages accepted by the daemon
+const MAX_MESSAGE_SIZE = 64 * 1024
+
+//Signer that asks the daemon listening on a Unix socket to sign the requests
+type Signer struct {
+	SocketPath string
+	KeyID      string //ID of the key in the daemon (usually the application or user ID)
+	client     *http.Client
+}
+
+//Returns a new signer that uses the key keyID of the daemon listening on socketPath
+func New(socketPath string, keyID string) *Signer {
+	return &Signer{
+		SocketPath: socketPath,
+		KeyID:      keyID,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+//Implementation of the golatch.Signer interface
+func (s *Signer) Sign(ctx context.Context, message []byte) ([]byte, error) {
+	signURL := "http://unix" + SIGN_PATH + "?key=" + url.QueryEscape(s.KeyID)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, signURL, bytes.NewReader(message))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/octet-stream")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signing daemon error [%d]: %s", response.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+//Handler of the daemon. It signs the messages with the HMAC-SHA1 signers of the keys it holds
+type Handler struct {
+	signers map[string]golatch.Signer
+}
+
+//Returns a new handler for the secret keys provided (by key ID)
+func NewHandler(secretKeys map[string]string) *Handler {
+	h := &Handler{signers: make(map[string]golatch.Signer, len(secretKeys))}
+	for keyID, secretKey := range secretKeys {
+		h.signers[keyID] = golatch.NewHMACSigner(secretKey)
+	}
+	return h
+}
+
+//Implementation of the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != SIGN_PATH {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	signer, ok := h.signers[r.URL.Query().Get("key")]
+	if !ok {
+		http.Error(w, "unknown key", http.StatusNotFound)
+		return
+	}
+	message, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MAX_MESSAGE_SIZE))
+	if err != nil {
+		http.Error(w, "message too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	signature, err := signer.Sign(r.Context(), message)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(signature)
+}
+
+//Listens on a Unix socket only accessible by the user running the daemon (a stale socket file is removed first)
+//The socket is created in a directory only accessible by that user, so nobody else can connect to it even before its
+//permissions are changed to 0600. The directory is created with 0700 permissions if it doesn't exist; if it exists,
+//it must not be accessible by other users (and on Unix systems it must belong to the user running the daemon)
+func Listen(socketPath string) (net.Listener, error) {
+	if err := privateDir(filepath.Dir(socketPath)); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+//Creates a directory only accessible by the current user, or checks that an existing one is
+func privateDir(dir string) error {
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("directory %s is accessible by other users (permissions %v), the socket must be in a private directory", dir, info.Mode().Perm())
+	}
+	return checkOwner(dir, info)
+}
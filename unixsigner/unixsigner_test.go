@@ -0,0 +1,79 @@
+package unixsigner
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/millenc/golatch"
+	"github.com/millenc/golatch/golatchtest"
+)
+
+//Starts a daemon with the keys provided on a Unix socket in a temporary directory
+func startTestDaemon(t *testing.T, secretKeys map[string]string) string {
+	//Unix socket paths are limited to ~100 characters, so t.TempDir() can't be used
+	dir, err := os.MkdirTemp("", "unixsigner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socketPath := filepath.Join(dir, "signer.sock")
+	listener, err := Listen(socketPath)
+	if err != nil {
+		t.Fatalf("Listen() failed: unexpected error %v", err)
+	}
+	server := &http.Server{Handler: NewHandler(secretKeys)}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return socketPath
+}
+
+func TestSign(t *testing.T) {
+	socketPath := startTestDaemon(t, map[string]string{"MyAppID": "MySecretKey"})
+
+	if info, err := os.Stat(socketPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Listen() failed: expected socket with 0600 permissions, got %v (error %v)", info.Mode().Perm(), err)
+	}
+
+	message := []byte("GET\n2015-02-15 14:53:00\n\n/api/1.0/status/MyAccountID")
+	expected, _ := golatch.NewHMACSigner("MySecretKey").Sign(context.Background(), message)
+	signature, err := New(socketPath, "MyAppID").Sign(context.Background(), message)
+	if err != nil || string(signature) != string(expected) {
+		t.Errorf("Sign() failed: expected %x, got %x (error %v)", expected, signature, err)
+	}
+
+	if _, err := New(socketPath, "MyOtherAppID").Sign(context.Background(), message); err == nil || !strings.Contains(err.Error(), "unknown key") {
+		t.Errorf("Sign() failed: expected error for an unknown key, got %v", err)
+	}
+	if _, err := New(socketPath+".missing", "MyAppID").Sign(context.Background(), message); err == nil {
+		t.Errorf("Sign() failed: expected error when the daemon is not running")
+	}
+}
+
+func TestSignRequests(t *testing.T) {
+	server := golatchtest.NewServer()
+	defer server.Close()
+	server.AddApplication("", "MyAppID", "MySecretKey", "My Application")
+	server.PairAccount("MyAppID", "MyAccountID")
+	socketPath := startTestDaemon(t, map[string]string{"MyAppID": "MySecretKey"})
+
+	latch := golatch.NewLatchWithSigner("MyAppID", New(socketPath, "MyAppID"))
+	latch.SetAPIURL(server.URL)
+	if status, err := latch.Status("MyAccountID", false, false); err != nil || status.Status() != golatch.LATCH_STATUS_ON {
+		t.Errorf("Status() failed: expected request signed by the daemon to be accepted, got %v (error %v)", status, err)
+	}
+
+	latch.SetSigner(New(socketPath+".missing", "MyAppID"))
+	if _, err := latch.Status("MyAccountID", false, false); !errors.Is(err, golatch.ErrSigningFailed) {
+		t.Errorf("Status() failed: expected ErrSigningFailed, got %v", err)
+	}
+	if len(server.Calls()) != 1 {
+		t.Errorf("Status() failed: expected unsigned requests not to be sent, got %d calls", len(server.Calls()))
+	}
+}